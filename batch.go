@@ -0,0 +1,163 @@
+package reactor
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// batchedWrite is a write that is being held back until the end of a batch.
+type batchedWrite struct {
+	source Initiator
+	prev, value interface{}
+	propagate WriteCallback
+}
+
+// batch is the state of the batches in progress on a single goroutine. It is
+// only used by that goroutine, so it needs no lock of its own.
+type batch struct {
+	depth int
+	queue []*batchedWrite
+	index map[Initiator]*batchedWrite
+}
+
+// batches holds the batch in progress on each goroutine, by goroutine ID.
+// batchCount is the number of goroutines with a batch in progress, so that
+// writes made while there are none need not look up their goroutine.
+var batchLock sync.Mutex
+var batches map[uint64]*batch
+var batchCount int32
+
+// returns the ID of the calling goroutine, as printed in its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	s := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id,_ := strconv.ParseUint(string(s), 10, 64)
+	return id
+}
+
+// returns the batch in progress on the calling goroutine, or nil if there is
+// none.
+func currentBatch() *batch {
+	if atomic.LoadInt32(&batchCount) == 0 {
+		return nil
+	}
+	id := goroutineID()
+	batchLock.Lock()
+		b := batches[id]
+	batchLock.Unlock()
+	return b
+}
+
+// Batch runs f, holding back the write callbacks and bindings of every
+// Initiator set during f until f returns. Values are still changed
+// immediately, so reading an Initiator during f returns the value last set.
+//
+// When f returns, each Initiator that was set runs its write callbacks and
+// bindings once, in the order the Initiators were first set. The values
+// passed to the callbacks are the value before the first write during f and
+// the final value. Writes caused by those callbacks and bindings are batched
+// in the same way, so an Indicator bound to several Initiators that changed
+// propagates its own value only once.
+//
+// Writes to parts of an Initiator, such as SetAt on a slice, are held back 
+// as well, but are not coalesced: each runs its callbacks and bindings once 
+// the Batch ends, in the order they were made among the other writes.
+//
+// Calls to Batch may be nested, in which case nothing is run until the
+// outermost call returns. Batch only applies to the goroutine that calls it:
+// writes made by other goroutines while f is running, including goroutines
+// started by f and concurrent bindings, run their callbacks and bindings
+// immediately, as usual.
+func Batch(f func()) {
+	id := goroutineID()
+	batchLock.Lock()
+		b,ok := batches[id]
+		if !ok {
+			if batches == nil {
+				batches = make(map[uint64]*batch)
+			}
+			b = &batch{}
+			batches[id] = b
+			atomic.AddInt32(&batchCount, 1)
+		}
+	batchLock.Unlock()
+
+	b.depth++
+	defer b.end(id)
+	f()
+}
+
+// ends a batch of b, the batch of the goroutine id, running every held-back
+// write if it is the outermost batch. The batch remains open while writes are
+// being run, so that any writes they cause are coalesced as well.
+func (b *batch) end(id uint64) {
+	if b.depth > 1 {
+		b.depth--
+		return
+	}
+	defer func() {
+		batchLock.Lock()
+			delete(batches, id)
+		batchLock.Unlock()
+		atomic.AddInt32(&batchCount, -1)
+	}()
+	for len(b.queue) > 0 {
+		w := b.queue[0]
+		b.queue = b.queue[1:]
+		delete(b.index, w.source)
+		w.propagate(w.prev, w.value)
+	}
+}
+
+// Batched holds back a write to source if a Batch is in progress on the
+// calling goroutine, and reports whether it did so. If it returns true,
+// propagate will be called with the first previous value and the final value
+// of source once the Batch ends, and should not be called by the caller. prev and v are the previous and new
+// values of this write.
+//
+// Batched is intended for use in implementing SetValue for Initiators outside
+// of this package, and its use is discouraged otherwise.
+func Batched(source Initiator, prev, v interface{}, propagate WriteCallback) bool {
+	b := currentBatch()
+	if b == nil {
+		return false
+	}
+
+	if w,ok := b.index[source]; ok {
+		w.value = v
+		return true
+	}
+
+	if b.index == nil {
+		b.index = make(map[Initiator]*batchedWrite)
+	}
+	w := &batchedWrite{source, prev, v, propagate}
+	b.index[source] = w
+	b.queue = append(b.queue, w)
+	return true
+}
+
+// Defer calls f, or if a Batch is in progress on the calling goroutine, holds
+// f back until the Batch 
+// ends. Unlike writes held back by Batched, deferred calls are not coalesced, 
+// and each is run once, in the order it was deferred among the held-back 
+// writes.
+//
+// Defer is intended for use in implementing writes to parts of Initiators 
+// outside of this package, such as single elements, and its use is 
+// discouraged otherwise.
+func Defer(f func()) {
+	b := currentBatch()
+	if b == nil {
+		f()
+		return
+	}
+	b.queue = append(b.queue, &batchedWrite{propagate: func(_, _ interface{}) {f()}})
+}
@@ -0,0 +1,149 @@
+package reactor
+
+import (
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	var trigger Trigger
+	var count int
+	var gotPrev, gotValue interface{}
+
+	trigger.SetValue(0)
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		count += 1
+		gotPrev, gotValue = prev, v
+	})
+
+	Batch(func() {
+		for i:=1; i<=10; i++ {
+			trigger.SetValue(i)
+			if trigger.Value() != i {
+				t.Fatalf("Value should change immediately in a batch. Want %d; got %v", i, trigger.Value())
+			}
+		}
+		if count != 0 {
+			t.Fatalf("Write callback run %d times during batch", count)
+		}
+	})
+
+	if count != 1 {
+		t.Fatalf("Expected write callback to run once; ran %d times", count)
+	}
+	if gotPrev != 0 || gotValue != 10 {
+		t.Fatalf("Expected callback with (0, 10); got (%v, %v)", gotPrev, gotValue)
+	}
+}
+
+func TestBatchBindings(t *testing.T) {
+	var t1,t2 Trigger
+	var count int
+
+	t1.SetValue(0)
+	t2.SetValue(0)
+
+	var ind Indicator
+	ind.AddBinding(&t1, func(v interface{}) interface{} {
+		return v.(int) + t2.Value().(int)
+	})
+	ind.AddBinding(&t2, func(v interface{}) interface{} {
+		return t1.Value().(int) + v.(int)
+	})
+	ind.AddWriteCallback(func(prev, v interface{}) {
+		count += 1
+	})
+
+	Batch(func() {
+		t1.SetValue(1)
+		t2.SetValue(2)
+		t1.SetValue(3)
+	})
+
+	if ind.Value() != 5 {
+		t.Fatalf("Expected bound value 5; got %v", ind.Value())
+	}
+	if count != 1 {
+		t.Fatalf("Expected dependent Indicator to propagate once; propagated %d times", count)
+	}
+}
+
+func TestBatchNested(t *testing.T) {
+	var trigger Trigger
+	var count int
+
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		count += 1
+	})
+
+	Batch(func() {
+		Batch(func() {
+			trigger.SetValue(1)
+		})
+		if count != 0 {
+			t.Fatal("Write callback run at the end of a nested batch")
+		}
+		trigger.SetValue(2)
+	})
+
+	if count != 1 {
+		t.Fatalf("Expected write callback to run once; ran %d times", count)
+	}
+}
+
+func TestBatchOrder(t *testing.T) {
+	var t1,t2 Trigger
+	var order []string
+
+	t1.AddWriteCallback(func(prev, v interface{}) {
+		order = append(order, "t1")
+	})
+	t2.AddWriteCallback(func(prev, v interface{}) {
+		order = append(order, "t2")
+	})
+
+	Batch(func() {
+		t2.SetValue(1)
+		t1.SetValue(1)
+		t2.SetValue(2)
+	})
+
+	if len(order) != 2 || order[0] != "t2" || order[1] != "t1" {
+		t.Fatalf("Expected callbacks in order of first write [t2 t1]; got %v", order)
+	}
+}
+
+func TestBatchOtherGoroutine(t *testing.T) {
+	var inside,outside Trigger
+	var insideRan,outsideRan bool
+	inside.AddWriteCallback(func(prev, v interface{}) {
+		insideRan = true
+	})
+	outside.AddWriteCallback(func(prev, v interface{}) {
+		outsideRan = true
+	})
+
+	started := make(chan bool)
+	release := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		Batch(func() {
+			Batch(func() {
+				inside.SetValue(1)
+				started <- true
+				<-release
+			})
+		})
+		done <- true
+	}()
+
+	<-started
+	outside.SetValue(1)
+	if !outsideRan {
+		t.Fatal("Expected writes on another goroutine to run their callbacks immediately")
+	}
+	close(release)
+	<-done
+	if !insideRan {
+		t.Fatal("Expected the batched write to run its callbacks once the Batch ended")
+	}
+}
@@ -10,7 +10,7 @@ func (r ReadCallback) Async() ReadCallback {
 func (r ReadCallback) Concurrent() ReadCallback {
 	conReadLock.Lock()
 		if conRead == nil {
-			conRead = make(chan conReadState, 100)
+			conRead = make(chan conReadState, queueCapacity)
 			go runConcurrentRead()
 		}
 	conReadLock.Unlock()
@@ -36,7 +36,7 @@ func (w WriteCallback) Async() WriteCallback {
 func (w WriteCallback) Concurrent() WriteCallback {
 	conWriteLock.Lock()
 		if conWrite == nil {
-			conWrite = make(chan conWriteState, 100)
+			conWrite = make(chan conWriteState, queueCapacity)
 			go runConcurrentWrite()
 		}
 	conWriteLock.Unlock()
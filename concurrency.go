@@ -18,8 +18,13 @@ type conBindState struct {
 	value interface{}
 	bound Binder
 	f BindingFunc
+	cause spanRef // the binding that queued it, for tracing
 }
 
+// queueCapacity is the number of entries each concurrent queue can hold before
+// queueing blocks.
+const queueCapacity = 100
+
 var conRead chan conReadState
 var conReadLock sync.Mutex
 var conWrite chan conWriteState
@@ -27,6 +32,52 @@ var conWriteLock sync.Mutex
 var conBind chan conBindState
 var conBindLock sync.Mutex
 
+// QueueBinding returns a BindingFunc that queues f to be run with the value 
+// passed to it, and b to be set to the result. Queued bindings are run in the 
+// order received, in a non-parallel fashion. The returned BindingFunc always 
+// returns nil, so it should be registered with AddBinder as concurrent.
+//
+// QueueBinding is intended for use in implementing AddConcurrentBinding for 
+// Binders outside of this package, and its use is discouraged otherwise.
+func QueueBinding(b Binder, f BindingFunc) BindingFunc {
+	conBindLock.Lock()
+		if conBind == nil {
+			conBind = make(chan conBindState, queueCapacity)
+			go runConcurrentBind()
+		}
+	conBindLock.Unlock()
+
+	return func(v interface{}) interface{} {
+		conBind <- conBindState{v, b, f, causeOf(b)}
+		return nil
+	}
+}
+
+// QueueStats describes one of the queues used to run concurrent callbacks and
+// bindings. Len is the number of entries waiting to run, and Cap is the number
+// that can wait before queueing another blocks.
+type QueueStats struct {
+	Name string `json:"name"`
+	Len int `json:"len"`
+	Cap int `json:"cap"`
+}
+
+// Queues returns the current state of the queues of concurrent read
+// callbacks, write callbacks, and bindings, named "read", "write" and "bind"
+// respectively. Queues that have not been used yet are empty.
+func Queues() []QueueStats {
+	conReadLock.Lock()
+		read := QueueStats{"read", len(conRead), queueCapacity}
+	conReadLock.Unlock()
+	conWriteLock.Lock()
+		write := QueueStats{"write", len(conWrite), queueCapacity}
+	conWriteLock.Unlock()
+	conBindLock.Lock()
+		bind := QueueStats{"bind", len(conBind), queueCapacity}
+	conBindLock.Unlock()
+	return []QueueStats{read, write, bind}
+}
+
 func runConcurrentRead() {
 	for c := range conRead {
 		c.f(c.value)
@@ -41,7 +92,18 @@ func runConcurrentWrite() {
 
 func runConcurrentBind() {
 	for b := range conBind {
-		b.bound.SetValue(b.f(b.value))
+		p,ok := b.bound.(probed)
+		if !ok {
+			Settle(b.bound, b.f(b.value))
+			continue
+		}
+		var start EventStart
+		if p.probe().current() != nil {
+			start = startSpan(p.probe(), b.cause)
+		}
+		v := b.f(b.value)
+		Settle(b.bound, v)
+		EmitEvent(p.probe(), Event{Kind: ConcurrentBindingEvent, Prev: b.value, Value: v}, start)
 	}
 }
 
@@ -0,0 +1,39 @@
+package reactor
+
+import (
+	"testing"
+)
+
+func TestQueues(t *testing.T) {
+	block := make(chan struct{})
+	started := make(chan struct{})
+	var once bool
+	w := WriteCallback(func(prev, v interface{}) {
+		if !once {
+			once = true
+			close(started)
+			<-block
+		}
+	}).Concurrent()
+
+	w(nil, 1)
+	<-started
+	w(nil, 2)
+	w(nil, 3)
+
+	queues := Queues()
+	close(block)
+
+	names := []string{"read", "write", "bind"}
+	if len(queues) != len(names) {
+		t.Fatalf("Expected %d queues; got %v", len(names), queues)
+	}
+	for n,q := range queues {
+		if q.Name != names[n] || q.Cap != queueCapacity {
+			t.Fatalf("Queue %d: Expected %s with capacity %d; got %+v", n, names[n], queueCapacity, q)
+		}
+	}
+	if queues[1].Len != 2 {
+		t.Fatalf("Expected 2 queued writes; got %d", queues[1].Len)
+	}
+}
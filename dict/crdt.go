@@ -0,0 +1,410 @@
+package dict
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// Timestamp is a hybrid logical clock timestamp. Wall is a physical time in
+// nanoseconds, Logical orders events that share a Wall time, and Node breaks
+// any remaining ties, so that timestamps from different nodes are never equal.
+type Timestamp struct {
+	Wall int64
+	Logical uint32
+	Node string
+}
+
+// Before reports whether t is ordered before u.
+func (t Timestamp) Before(u Timestamp) bool {
+	if t.Wall != u.Wall {
+		return t.Wall < u.Wall
+	}
+	if t.Logical != u.Logical {
+		return t.Logical < u.Logical
+	}
+	return t.Node < u.Node
+}
+
+// Clock is a hybrid logical clock. The timestamps it returns follow physical
+// time as closely as possible, but always increase, and are always ordered
+// after every timestamp passed to Observe. A Clock must be created with
+// NewClock. It is safe to use a Clock from multiple goroutines.
+type Clock struct {
+	lock sync.Mutex
+	node string
+	last Timestamp
+	now func() int64
+}
+
+// NewClock returns a Clock for the node named node. Every node writing to
+// the same CRDT should have a different name.
+func NewClock(node string) *Clock {
+	return &Clock{node: node, now: func() int64 {return time.Now().UnixNano()}}
+}
+
+// Now returns a timestamp ordered after every timestamp previously returned
+// by or passed to c.
+func (c *Clock) Now() Timestamp {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	wall := c.now()
+	if wall > c.last.Wall {
+		c.last = Timestamp{Wall: wall}
+	} else {
+		c.last.Logical++
+	}
+	c.last.Node = c.node
+	return c.last
+}
+
+// Observe advances c past remote, a timestamp received from another node.
+func (c *Clock) Observe(remote Timestamp) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	wall := c.now()
+	switch {
+	case wall > c.last.Wall && wall > remote.Wall:
+		c.last = Timestamp{Wall: wall}
+	case remote.Wall > c.last.Wall:
+		c.last = Timestamp{Wall: remote.Wall, Logical: remote.Logical+1}
+	case c.last.Wall > remote.Wall:
+		c.last.Logical++
+	default:
+		if remote.Logical > c.last.Logical {
+			c.last.Logical = remote.Logical
+		}
+		c.last.Logical++
+	}
+	c.last.Node = c.node
+}
+
+// Register is the last-writer-wins register of a single key of a CRDT. A
+// deleted key keeps its Register as a tombstone, so that the delete wins over
+// older writes received later, until it is removed by CRDT.Compact.
+type Register struct {
+	Value interface{}
+	Time Timestamp
+	Deleted bool
+}
+
+// State is the replicated state of a CRDT, which maps every key that has
+// been written to its Register. A State can be encoded as JSON to be sent to
+// other replicas, and decoded with DecodeState.
+type State map[interface{}]Register
+
+// CRDT is a Trigger whose value can be written by several replicas
+// independently and merged, as a map of last-writer-wins registers, with
+// deleted keys kept as tombstones. Every key written with Set, Delete,
+// SetValue, CompareAndSet or Update records the new value in a Register,
+// timestamped by the Clock of the CRDT. Merging the State of another replica
+// keeps whichever Register of each key was written last, so every replica that
+// has merged the same writes holds the same value, regardless of the order
+// they were merged in.
+//
+// Writes and deletes of a key are ordered by timestamp alone, so a delete
+// wins over a concurrent write with an earlier timestamp, even on the replica
+// that made the write. This differs from an observed-remove map, where only
+// writes the deleting replica had seen are removed. Tombstones are kept until
+// they are removed by Compact.
+//
+// The other methods of the embedded Trigger, and any Entry or Entries created
+// from it, write the value directly, without recording Registers, so such
+// writes are not replicated. A CRDT must be created with NewCRDT.
+type CRDT struct {
+	Trigger
+
+	lock sync.Mutex
+	clock *Clock
+	registers State
+}
+
+// NewCRDT returns an empty CRDT for the node named node. Every replica
+// should have a different name.
+func NewCRDT(node string) *CRDT {
+	return &CRDT{clock: NewClock(node), registers: make(State)}
+}
+
+// Clock returns the Clock used to timestamp writes to c.
+func (c *CRDT) Clock() *Clock {
+	return c.clock
+}
+
+// records the Register of every key that differs between prev and next. Must
+// be called while holding c.lock.
+func (c *CRDT) record(prev, next map[interface{}]interface{}) {
+	changed := changedKeys(prev, next)
+	if len(changed) == 0 {
+		return
+	}
+	now := c.clock.Now()
+	for _,p := range changed {
+		_,exists := next[p.Key]
+		c.registers[p.Key] = Register{Value: p.Value, Time: now, Deleted: !exists}
+	}
+}
+
+// Set sets the value of key, as described for Trigger.Set, and records it in
+// the Register of key.
+func (c *CRDT) Set(key, value interface{}) {
+	c.lock.Lock()
+		c.registers[key] = Register{Value: value, Time: c.clock.Now()}
+		c.Trigger.Lock.Lock()
+			if c.value == nil {
+				c.value = make(map[interface{}]interface{})
+			}
+			prev := c.value[key]
+			c.value[key] = value
+			c.version++
+			pending := c.affected(Pair{key, value})
+		c.Trigger.Lock.Unlock()
+	c.lock.Unlock()
+
+	c.keyWritten(Pair{key, prev}, Pair{key, value}, pending)
+}
+
+// Delete removes key, as described for Trigger.Delete, and records a
+// tombstone in the Register of key.
+func (c *CRDT) Delete(key interface{}) {
+	c.lock.Lock()
+		c.registers[key] = Register{Time: c.clock.Now(), Deleted: true}
+		c.Trigger.Lock.Lock()
+			if c.value == nil {
+				c.value = make(map[interface{}]interface{})
+			}
+			prev,existed := c.value[key]
+			delete(c.value, key)
+			c.version++
+			var pending []pendingBinding
+			if existed {
+				pending = c.affected(Pair{key, nil})
+			}
+		c.Trigger.Lock.Unlock()
+	c.lock.Unlock()
+
+	c.keyWritten(Pair{key, prev}, Pair{key, nil}, pending)
+}
+
+// SetValue sets the value of c, as described for Trigger.SetValue, and
+// records the Register of every key that changed, including tombstones for
+// keys that were removed.
+func (c *CRDT) SetValue(v interface{}) {
+	m := v.(map[interface{}]interface{})
+	c.lock.Lock()
+		c.Trigger.Lock.Lock()
+			c.record(c.value, m)
+			prev := c.set(m)
+		c.Trigger.Lock.Unlock()
+	c.lock.Unlock()
+
+	if reactor.Batched(&c.Trigger, prev, m, c.propagate) {
+		return
+	}
+	c.propagate(prev, m)
+}
+
+// CompareAndSet sets the value of c as if by SetValue, but only if the
+// version of c is still version, as described for Trigger.CompareAndSet.
+func (c *CRDT) CompareAndSet(version uint64, v interface{}) bool {
+	m := v.(map[interface{}]interface{})
+	c.lock.Lock()
+		c.Trigger.Lock.Lock()
+			ok := c.version == version
+			var prev map[interface{}]interface{}
+			if ok {
+				c.record(c.value, m)
+				prev = c.set(m)
+			}
+		c.Trigger.Lock.Unlock()
+	c.lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	if !reactor.Batched(&c.Trigger, prev, m, c.propagate) {
+		c.propagate(prev, m)
+	}
+	return true
+}
+
+// Update sets the value of c to the result of f, as described for
+// Trigger.Update, and records the Register of every key that changed. If f
+// returns reactor.Unchanged, nothing is written or recorded.
+func (c *CRDT) Update(f func(interface{}) interface{}) interface{} {
+	c.lock.Lock()
+		c.Trigger.Lock.Lock()
+			next := f(copyMap(c.value))
+			if next == reactor.Unchanged {
+				result := copyMap(c.value)
+				c.Trigger.Lock.Unlock()
+				c.lock.Unlock()
+				return result
+			}
+			m := next.(map[interface{}]interface{})
+			c.record(c.value, m)
+			prev := c.set(m)
+			result := copyMap(m)
+		c.Trigger.Lock.Unlock()
+	c.lock.Unlock()
+
+	if !reactor.Batched(&c.Trigger, prev, m, c.propagate) {
+		c.propagate(prev, m)
+	}
+	return result
+}
+
+// State returns a copy of the Registers of c, to be merged into other
+// replicas.
+func (c *CRDT) State() State {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s := make(State, len(c.registers))
+	for k,r := range c.registers {
+		s[k] = r
+	}
+	return s
+}
+
+// Merge merges s, the State of another replica, into c. For every key, the
+// Register written last is kept, and the clock of c is advanced past every
+// timestamp in s, so later local writes win over them.
+//
+// The value of every key whose resolved value changed is updated, and
+// key-level write callbacks and watchers are run for those keys only, as if by
+// Set or Delete. If any key changed, write callbacks, watchers and bindings of
+// the whole map are then run once, as if by SetValue, and so are key bindings
+// of the changed keys. Keys whose value is unchanged, including those whose
+// Register was replaced by an equal value, produce no events. Merge returns
+// the keys whose value changed, in no particular order.
+func (c *CRDT) Merge(s State) []interface{} {
+	var writes [][2]Pair
+	var prev, next map[interface{}]interface{}
+
+	c.lock.Lock()
+		c.Trigger.Lock.Lock()
+			prev = c.value
+			value := copyMap(prev)
+			for k,r := range s {
+				c.clock.Observe(r.Time)
+				local,exists := c.registers[k]
+				if exists && !local.Time.Before(r.Time) {
+					continue
+				}
+				c.registers[k] = r
+
+				old,had := value[k]
+				if r.Deleted {
+					if !had {
+						continue
+					}
+					delete(value, k)
+					writes = append(writes, [2]Pair{{k, old}, {k, nil}})
+					continue
+				}
+				if had && reflect.DeepEqual(old, r.Value) {
+					continue
+				}
+				value[k] = r.Value
+				writes = append(writes, [2]Pair{{k, old}, {k, r.Value}})
+			}
+			if len(writes) > 0 {
+				c.value = value
+				c.version++
+				next = copyMap(value)
+			}
+		c.Trigger.Lock.Unlock()
+	c.lock.Unlock()
+
+	if len(writes) == 0 {
+		return nil
+	}
+	if prev == nil {
+		prev = make(map[interface{}]interface{})
+	}
+
+	keys := make([]interface{}, len(writes))
+	for n,w := range writes {
+		// Key bindings are run once, by propagate.
+		c.keyWritten(w[0], w[1], nil)
+		keys[n] = w[1].Key
+	}
+	if !reactor.Batched(&c.Trigger, prev, next, c.propagate) {
+		c.propagate(prev, next)
+	}
+	return keys
+}
+
+// Compact removes every tombstone written before t from the State of c,
+// returning the number removed. Tombstones are otherwise kept forever, so the
+// State of a CRDT grows with every key ever written.
+//
+// Compact must only be called with a time that every replica has merged all
+// writes up to, and no replica may merge a State taken from before the
+// compaction afterwards. Otherwise, an older write of a removed key that is
+// merged later has nothing to lose to, and the key reappears.
+func (c *CRDT) Compact(t Timestamp) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	removed := 0
+	for k,r := range c.registers {
+		if r.Deleted && r.Time.Before(t) {
+			delete(c.registers, k)
+			removed++
+		}
+	}
+	return removed
+}
+
+// MarshalJSON implements json.Marshaler, encoding s as a JSON object of
+// Registers, so it can be sent to other replicas. Keys are encoded by
+// EncodeKey, so if two keys encode to the same string, only one of them is
+// kept.
+func (s State) MarshalJSON() ([]byte, error) {
+	n := make(map[string]Register, len(s))
+	for k,r := range s {
+		key,err := EncodeKey(k)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = r
+	}
+	return json.Marshal(n)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding data as encoded by
+// MarshalJSON, with keys left as strings. Use DecodeState to recover keys of
+// other types.
+func (s *State) UnmarshalJSON(data []byte) error {
+	state,err := DecodeState(data, nil)
+	if err != nil {
+		return err
+	}
+	*s = state
+	return nil
+}
+
+// DecodeState decodes data as encoded by State.MarshalJSON, converting keys
+// with d. If d is nil, keys are left as strings. Values are decoded as they
+// would be into an interface{}, so numbers become float64, for example, and
+// are only equal to local values of the same type when merged.
+func DecodeState(data []byte, d KeyDecoder) (State, error) {
+	var n map[string]Register
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	s := make(State, len(n))
+	for k,r := range n {
+		var key interface{} = k
+		if d != nil {
+			var err error
+			if key,err = d(k); err != nil {
+				return nil, err
+			}
+		}
+		s[key] = r
+	}
+	return s, nil
+}
@@ -0,0 +1,325 @@
+package dict
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// returns a Clock for node whose physical time is read from *wall.
+func fixedClock(node string, wall *int64) *Clock {
+	c := NewClock(node)
+	c.now = func() int64 {return *wall}
+	return c
+}
+
+func TestTimestampBefore(t *testing.T) {
+	a := Timestamp{Wall: 1, Logical: 5, Node: "b"}
+	b := Timestamp{Wall: 2, Node: "a"}
+	c := Timestamp{Wall: 2, Logical: 1, Node: "a"}
+	d := Timestamp{Wall: 2, Logical: 1, Node: "b"}
+
+	ordered := []Timestamp{a, b, c, d}
+	for n := range ordered {
+		for m := range ordered {
+			if got := ordered[n].Before(ordered[m]); got != (n < m) {
+				t.Fatalf("%v.Before(%v): Expected %v; got %v", ordered[n], ordered[m], n < m, got)
+			}
+		}
+	}
+}
+
+func TestClockMonotonic(t *testing.T) {
+	wall := int64(100)
+	c := fixedClock("a", &wall)
+
+	first := c.Now()
+	second := c.Now()
+	if !first.Before(second) {
+		t.Fatalf("Expected %v before %v when physical time stands still", first, second)
+	}
+
+	wall = 50
+	third := c.Now()
+	if !second.Before(third) {
+		t.Fatalf("Expected %v before %v when physical time goes backwards", second, third)
+	}
+
+	wall = 200
+	if got := c.Now(); got != (Timestamp{Wall: 200, Node: "a"}) {
+		t.Fatalf("Expected clock to follow physical time; got %v", got)
+	}
+}
+
+func TestClockObserve(t *testing.T) {
+	wall := int64(100)
+	c := fixedClock("a", &wall)
+
+	remote := Timestamp{Wall: 1000, Logical: 3, Node: "b"}
+	c.Observe(remote)
+	if got := c.Now(); !remote.Before(got) {
+		t.Fatalf("Expected %v after observed %v", got, remote)
+	}
+}
+
+// returns two replicas with clocks that share a physical time.
+func crdtPair() (*CRDT, *CRDT, *int64) {
+	wall := int64(1)
+	a,b := NewCRDT("a"), NewCRDT("b")
+	a.clock = fixedClock("a", &wall)
+	b.clock = fixedClock("b", &wall)
+	return a, b, &wall
+}
+
+func TestCRDTConverges(t *testing.T) {
+	for _,order := range []string{"ab", "ba"} {
+		a,b,wall := crdtPair()
+
+		a.Set("shared", "from a")
+		a.Set("a only", 1)
+		*wall = 2
+		b.Set("shared", "from b")
+		b.Set("b only", 2)
+		b.Delete("a only")
+
+		sa,sb := a.State(), b.State()
+		if order == "ab" {
+			a.Merge(sb)
+			b.Merge(sa)
+		} else {
+			b.Merge(sa)
+			a.Merge(sb)
+		}
+
+		want := map[interface{}]interface{}{"shared": "from b", "b only": 2}
+		if got := a.Value(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Replica a, order %s: Expected %v; got %v", order, want, got)
+		}
+		if got := b.Value(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Replica b, order %s: Expected %v; got %v", order, want, got)
+		}
+	}
+}
+
+func TestCRDTMergeIdempotent(t *testing.T) {
+	a,b,_ := crdtPair()
+	a.Set("x", 1)
+	b.Merge(a.State())
+
+	version := b.Version()
+	if changed := b.Merge(a.State()); len(changed) != 0 {
+		t.Fatalf("Expected no changes merging the same state twice; got %v", changed)
+	}
+	if b.Version() != version {
+		t.Fatalf("Expected version %d to be unchanged; got %d", version, b.Version())
+	}
+}
+
+func TestCRDTMergeKeyEvents(t *testing.T) {
+	a,b,wall := crdtPair()
+	a.Set("same", "value")
+	a.Set("old", "value")
+	a.Set("gone", "value")
+	b.Merge(a.State())
+
+	*wall = 2
+	a.Set("same", "value")
+	a.Set("old", "new")
+	a.Delete("gone")
+	a.Set("added", 1)
+
+	var events []string
+	b.AddKeyWriteCallback(func(prev, v interface{}) {
+		events = append(events, v.(Pair).Key.(string))
+	})
+	writes := 0
+	b.AddWriteCallback(func(prev, v interface{}) {
+		writes++
+	})
+
+	changed := b.Merge(a.State())
+
+	sort.Strings(events)
+	want := []string{"added", "gone", "old"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("Expected key events for %v; got %v", want, events)
+	}
+	if len(changed) != len(want) {
+		t.Fatalf("Expected %d changed keys; got %v", len(want), changed)
+	}
+	if writes != 1 {
+		t.Fatalf("Expected 1 whole-value write event; got %d", writes)
+	}
+}
+
+func TestCRDTMergeKeyBinding(t *testing.T) {
+	a,b,wall := crdtPair()
+	var out reactor.Indicator
+	b.AddKeyBinder("watched", &out, func(v interface{}) interface{} {
+		return v.(Pair).Value
+	}, false)
+
+	*wall = 2
+	a.Set("watched", "yes")
+	a.Set("ignored", "no")
+	b.Merge(a.State())
+
+	if got := out.Value(); got != "yes" {
+		t.Fatalf("Expected binding to produce %q; got %v", "yes", got)
+	}
+}
+
+func TestCRDTMergeNotifiesWholeMap(t *testing.T) {
+	a,b,wall := crdtPair()
+	var out reactor.Indicator
+	out.AddBinding(b, func(v interface{}) interface{} {
+		return len(v.(map[interface{}]interface{}))
+	})
+	keyRuns := 0
+	var keyOut reactor.Indicator
+	b.AddKeyBinder("x", &keyOut, func(v interface{}) interface{} {
+		keyRuns++
+		return v.(Pair).Value
+	}, false)
+	changes := b.Watch(context.Background(), reactor.Buffered(1))
+
+	*wall = 2
+	a.Set("x", 1)
+	a.Set("y", 2)
+	b.Merge(a.State())
+
+	if got := out.Value(); got != 2 {
+		t.Fatalf("Expected binding on the whole map to produce 2; got %v", got)
+	}
+	if keyRuns != 1 {
+		t.Fatalf("Expected key binding to run once; ran %d times", keyRuns)
+	}
+	c := <-changes
+	want := map[interface{}]interface{}{"x": 1, "y": 2}
+	if !reflect.DeepEqual(c.Value, want) || len(c.Prev.(map[interface{}]interface{})) != 0 {
+		t.Fatalf("Unexpected change %+v", c)
+	}
+}
+
+func TestCRDTStateJSON(t *testing.T) {
+	a,b,wall := crdtPair()
+	a.Set(1, "one")
+	a.Set(2, "two")
+	*wall = 2
+	a.Delete(2)
+
+	data,err := json.Marshal(a.State())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s,err := DecodeState(data, func(k string) (interface{}, error) {
+		return strconv.Atoi(k)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(s, a.State()) {
+		t.Fatalf("Expected %v after decoding; got %v", a.State(), s)
+	}
+
+	b.Merge(s)
+	if got := b.Value(); !reflect.DeepEqual(got, map[interface{}]interface{}{1: "one"}) {
+		t.Fatalf("Unexpected value after merging decoded state: %v", got)
+	}
+
+	var raw State
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if r,ok := raw["2"]; !ok || !r.Deleted {
+		t.Fatalf("Expected string keys without a KeyDecoder; got %v", raw)
+	}
+}
+
+func TestCRDTCompact(t *testing.T) {
+	a,_,wall := crdtPair()
+	a.Set("kept", 1)
+	a.Set("old", 1)
+	a.Delete("old")
+	*wall = 5
+	a.Delete("new")
+
+	if n := a.Compact(Timestamp{Wall: 5}); n != 1 {
+		t.Fatalf("Expected 1 tombstone removed; got %d", n)
+	}
+	s := a.State()
+	if _,ok := s["old"]; ok {
+		t.Fatal("Expected old tombstone to be removed")
+	}
+	if r,ok := s["new"]; !ok || !r.Deleted {
+		t.Fatal("Expected newer tombstone to be kept")
+	}
+	if _,ok := s["kept"]; !ok {
+		t.Fatal("Expected live registers to be kept")
+	}
+}
+
+func TestCRDTDeleteWinsOverOlderWrite(t *testing.T) {
+	a,b,wall := crdtPair()
+	a.Set("k", "v")
+	b.Merge(a.State())
+
+	*wall = 2
+	b.Delete("k")
+
+	old := State{"k": {Value: "stale", Time: Timestamp{Wall: 1, Node: "c"}}}
+	b.Merge(old)
+	if _,ok := b.GetCheck("k"); ok {
+		t.Fatal("Expected tombstone to win over an older write")
+	}
+
+	a.Merge(b.State())
+	if _,ok := a.GetCheck("k"); ok {
+		t.Fatal("Expected delete to be merged")
+	}
+}
+
+func TestCRDTSetValueRecordsRegisters(t *testing.T) {
+	a,b,wall := crdtPair()
+	a.SetValue(map[interface{}]interface{}{"x": 1, "y": 2})
+	b.Merge(a.State())
+
+	*wall = 2
+	a.Update(func(v interface{}) interface{} {
+		m := v.(map[interface{}]interface{})
+		delete(m, "x")
+		m["y"] = 3
+		return m
+	})
+	b.Merge(a.State())
+
+	want := map[interface{}]interface{}{"y": 3}
+	if got := b.Value(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v; got %v", want, got)
+	}
+	if r := a.State()["x"]; !r.Deleted {
+		t.Fatalf("Expected a tombstone for removed key; got %+v", r)
+	}
+}
+
+func TestCRDTLocalWriteAfterMergeWins(t *testing.T) {
+	wall := int64(1)
+	a,b := NewCRDT("a"), NewCRDT("b")
+	a.clock = fixedClock("a", &wall)
+	future := int64(1000)
+	b.clock = fixedClock("b", &future)
+
+	b.Set("k", "from b")
+	a.Merge(b.State())
+	a.Set("k", "from a")
+	b.Merge(a.State())
+
+	if got := b.Get("k"); got != "from a" {
+		t.Fatalf("Expected later local write to win despite clock skew; got %v", got)
+	}
+}
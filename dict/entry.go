@@ -0,0 +1,160 @@
+package dict
+
+import (
+	"reflect"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// keyBinding is a reactor.Binding that depends on a set of keys of a Trigger,
+// rather than the entire map. A keyBinding with no keys depends on every key.
+type keyBinding struct {
+	keys []interface{}
+	reactor.Binding
+}
+
+// matches reports whether a change to key requires k to be run.
+func (k keyBinding) matches(key interface{}) bool {
+	if len(k.keys) == 0 {
+		return true
+	}
+	for _,x := range k.keys {
+		if x == key {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingBinding is a key binding waiting to be run, along with the Pair that
+// will be passed to it.
+type pendingBinding struct {
+	reactor.Binding
+	pair Pair
+}
+
+func runPending(probe *reactor.Probe, pending []pendingBinding) {
+	for _,p := range pending {
+		reactor.RunBinding(probe, p.Binding, p.pair)
+	}
+}
+
+// changedKeys returns the Pairs from next for every key whose value differs
+// between prev and next. Keys missing from next are paired with nil.
+func changedKeys(prev, next map[interface{}]interface{}) []Pair {
+	var pairs []Pair
+	for k,v := range next {
+		p,exists := prev[k]
+		if !exists || !reflect.DeepEqual(p, v) {
+			pairs = append(pairs, Pair{k, v})
+		}
+	}
+	for k := range prev {
+		if _,exists := next[k]; !exists {
+			pairs = append(pairs, Pair{k, nil})
+		}
+	}
+	return pairs
+}
+
+// Entry is a reactor.Initiator representing a single key of a Trigger.
+// Binders bound to an Entry are only updated when the value associated with
+// that key changes.
+type Entry struct {
+	t *Trigger
+	key interface{}
+}
+
+// Entry returns an Entry representing key in t.
+func (t *Trigger) Entry(key interface{}) *Entry {
+	return &Entry{t, key}
+}
+
+// Value returns the key represented by e and its associated value, as a Pair
+// struct. If the key does not exist in the Trigger, the Pair is (key, <nil>).
+//
+// Any callbacks registered with AddKeyReadCallback on the Trigger will be
+// called, as if by Get.
+func (e *Entry) Value() interface{} {
+	return Pair{e.key, e.t.Get(e.key)}
+}
+
+// SetValue sets the value associated with the key represented by e to v, as
+// if by Set.
+func (e *Entry) SetValue(v interface{}) {
+	e.t.Set(e.key, v)
+}
+
+// AddBinder adds a Binder to be executed when the value associated with the
+// key represented by e changes. This is equivalent to calling AddKeyBinder on
+// the underlying Trigger.
+func (e *Entry) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	e.t.AddKeyBinder(e.key, b, f, concurrent)
+}
+
+// Entries is a reactor.Initiator representing a set of keys of a Trigger.
+// Binders bound to Entries are updated whenever the value associated with
+// any of those keys changes, and are passed the Pair for the affected key. If
+// Entries was created without any keys, it represents every key of the Trigger.
+//
+// Since there is no single affected key when a delayed binding is evaluated, 
+// delayed bindings on Entries are passed the result of Value instead.
+type Entries struct {
+	t *Trigger
+	keys []interface{}
+}
+
+// Entries returns an Entries representing keys in t. If no keys are given,
+// the result represents every key in t.
+func (t *Trigger) Entries(keys ...interface{}) *Entries {
+	return &Entries{t, keys}
+}
+
+// Value returns a copy of the portion of the Trigger's map represented by e.
+// Keys that do not exist in the Trigger are omitted.
+//
+// Any callbacks registered with AddReadCallback on the Trigger will be
+// called, passing the same map.
+func (e *Entries) Value() interface{} {
+	e.t.Lock.Lock()
+		var m map[interface{}]interface{}
+		if len(e.keys) == 0 {
+			m = copyMap(e.t.value)
+		} else {
+			m = make(map[interface{}]interface{})
+			for _,k := range e.keys {
+				if v,exists := e.t.value[k]; exists {
+					m[k] = v
+				}
+			}
+		}
+	e.t.Lock.Unlock()
+
+	start := reactor.StartEvent(&e.t.Probe)
+	for _,c := range e.t.readCallbacks {
+		c(m)
+	}
+	reactor.EmitEvent(&e.t.Probe, reactor.Event{Kind: reactor.ReadEvent, Value: m}, start)
+
+	return m
+}
+
+// SetValue sets each key-value pair in v, as if by Set. v is tested to ensure
+// that it is of type map[interface{}]interface{}, and if not, SetValue panics.
+// Keys in v that are not represented by e are ignored.
+func (e *Entries) SetValue(v interface{}) {
+	m := v.(map[interface{}]interface{})
+	k := keyBinding{keys: e.keys}
+	for key,val := range m {
+		if k.matches(key) {
+			e.t.Set(key, val)
+		}
+	}
+}
+
+// AddBinder adds a Binder to be executed when the value associated with any
+// key represented by e changes. This is equivalent to calling AddKeysBinder on
+// the underlying Trigger.
+func (e *Entries) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	e.t.AddKeysBinder(e.keys, b, f, concurrent)
+}
@@ -0,0 +1,161 @@
+package dict
+
+import (
+	"testing"
+	"reflect"
+
+	"github.com/KellenWatt/reactor"
+)
+
+func TestEntryBinding(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var count int
+	bindFunc := func(v interface{}) interface{} {
+		count += 1
+		return v.(Pair).Value
+	}
+
+	trigger.SetValue(initMap())
+	ind.AddBinding(trigger.Entry("port"), bindFunc)
+
+	trigger.Set(1, "uno")
+	if count != 0 {
+		t.Fatal("Binding on key run for change to another key")
+	}
+
+	trigger.Set("port", 8080)
+	if count != 1 || ind.Value() != 8080 {
+		t.Fatalf("Expected binding to run once with 8080; ran %d times with %v", count, ind.Value())
+	}
+
+	trigger.Delete("port")
+	if count != 2 || ind.Value() != nil {
+		t.Fatalf("Expected binding to run for Delete with nil; ran %d times with %v", count, ind.Value())
+	}
+
+	trigger.Delete("port")
+	if count != 2 {
+		t.Fatal("Binding run for Delete of a key that does not exist")
+	}
+}
+
+func TestEntryBindingSetValue(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var count int
+	bindFunc := func(v interface{}) interface{} {
+		count += 1
+		return v
+	}
+
+	trigger.SetValue(initMap())
+	ind.AddBinding(trigger.Entry(1), bindFunc)
+
+	m := initMap()
+	m[2] = "dos"
+	trigger.SetValue(m)
+	if count != 0 {
+		t.Fatal("Binding run by SetValue that did not change key")
+	}
+
+	m[1] = "uno"
+	trigger.SetValue(m)
+	if count != 1 || ind.Value() != (Pair{1, "uno"}) {
+		t.Fatalf("Expected binding to run once with %v; ran %d times with %v", Pair{1, "uno"}, count, ind.Value())
+	}
+}
+
+func TestEntriesBinding(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var keys []interface{}
+	bindFunc := func(v interface{}) interface{} {
+		keys = append(keys, v.(Pair).Key)
+		return v
+	}
+
+	ind.AddBinding(trigger.Entries("host", "port"), bindFunc)
+
+	trigger.Set("host", "localhost")
+	trigger.Set("user", "admin")
+	trigger.Set("port", 80)
+	trigger.Delete("host")
+
+	want := []interface{}{"host", "port", "host"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Expected binding to run for keys %v; got %v", want, keys)
+	}
+}
+
+func TestEntriesBindingAny(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var count int
+	bindFunc := func(v interface{}) interface{} {
+		count += 1
+		return v
+	}
+
+	ind.AddBinding(trigger.Entries(), bindFunc)
+
+	trigger.Set("a", 1)
+	trigger.Set("b", 2)
+	trigger.Delete("a")
+	if count != 3 {
+		t.Fatalf("Expected binding to run for every key-level change; ran %d times", count)
+	}
+}
+
+func TestEntryValue(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(initMap())
+
+	if got := trigger.Entry(1).Value(); got != (Pair{1, "one"}) {
+		t.Fatalf("Expected %v; got %v", Pair{1, "one"}, got)
+	}
+
+	trigger.Entry(1).SetValue("uno")
+	if got := trigger.Get(1); got != "uno" {
+		t.Fatalf("Expected Entry.SetValue to set key; got %v", got)
+	}
+}
+
+func TestEntriesValue(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(initMap())
+
+	want := map[interface{}]interface{}{1: "one", 2: "two"}
+	if got := trigger.Entries(1, 2, "missing").Value(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v; got %v", want, got)
+	}
+
+	trigger.Entries(1).SetValue(map[interface{}]interface{}{1: "uno", 2: "dos"})
+	if trigger.Get(1) != "uno" || trigger.Get(2) != "two" {
+		t.Fatalf("Expected only represented keys to be set; got %v", trigger.Value())
+	}
+}
+
+func TestEntryBindingBatch(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var count int
+	bindFunc := func(v interface{}) interface{} {
+		count += 1
+		return v
+	}
+
+	trigger.SetValue(initMap())
+	ind.AddBinding(trigger.Entry(1), bindFunc)
+
+	reactor.Batch(func() {
+		m := initMap()
+		m[1] = "uno"
+		trigger.SetValue(m)
+		trigger.SetValue(initMap())
+	})
+
+	if count != 0 {
+		t.Fatalf("Binding run for key that ended the batch unchanged; ran %d times", count)
+	}
+}
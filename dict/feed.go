@@ -0,0 +1,46 @@
+package dict
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SyntaxError is the error returned by ReadPairs for a line that is not a 
+// key=value pair.
+type SyntaxError struct {
+	Line int
+	Text string
+}
+
+// Implementation of the standard error interface.
+func (s *SyntaxError) Error() string {
+	return fmt.Sprintf("Line %d is not a key=value pair: %q", s.Line, s.Text)
+}
+
+// ReadPairs reads r line by line, parsing each line as a key=value pair and 
+// setting it in t, as if by Set. Keys and values are strings, with surrounding 
+// whitespace removed. Blank lines and lines starting with '#' are ignored.
+//
+// ReadPairs blocks until r returns io.EOF, in which case the error is nil. If 
+// r returns any other error, or a line is not a key=value pair, ReadPairs 
+// stops and returns that error. Pairs read before the error remain set.
+func ReadPairs(r io.Reader, t *Trigger) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return &SyntaxError{line, text}
+		}
+		t.Set(strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]))
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,39 @@
+package dict
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadPairs(t *testing.T) {
+	var trigger Trigger
+	var count int
+
+	trigger.AddKeyWriteCallback(func(prev, v interface{}) {
+		count += 1
+	})
+
+	input := "# config\nhost = localhost\n\nport=8080\nurl=http://x/?a=b\n"
+	if err := ReadPairs(strings.NewReader(input), &trigger); err != nil {
+		t.Fatalf("Expected nil error; got %v", err)
+	}
+
+	want := map[interface{}]interface{}{"host": "localhost", "port": "8080", "url": "http://x/?a=b"}
+	if !reflect.DeepEqual(trigger.Value(), want) || count != 3 {
+		t.Fatalf("Expected %v from 3 writes; got %v from %d", want, trigger.Value(), count)
+	}
+}
+
+func TestReadPairsSyntaxError(t *testing.T) {
+	var trigger Trigger
+
+	err := ReadPairs(strings.NewReader("a=1\nbad line\nb=2"), &trigger)
+	syntax,ok := err.(*SyntaxError)
+	if !ok || syntax.Line != 2 {
+		t.Fatalf("Expected SyntaxError on line 2; got %v", err)
+	}
+	if trigger.Get("a") != "1" || trigger.Size() != 1 {
+		t.Fatalf("Expected only pairs before the error to be set; got %v", trigger.Value())
+	}
+}
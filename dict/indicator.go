@@ -0,0 +1,268 @@
+package dict
+
+import (
+	"github.com/KellenWatt/reactor"
+)
+
+// Indicator implements reactor.Binder for the special case of maps. An
+// Indicator provides all of the functionality of Trigger, including key-level
+// access and callbacks, but its value can also be bound to one or more
+// reactor.Initiators.
+//
+// Every BindingFunc used to bind an Indicator must return a
+// map[interface{}]interface{}, or setting the value of the Indicator will
+// panic, as described for Trigger.SetValue.
+//
+// When a binding sets the value of an Indicator, callbacks registered with
+// AddKeyWriteCallback are called for each key whose value actually changed,
+// in addition to any callbacks registered with AddWriteCallback.
+type Indicator struct {
+	Trigger
+
+	err error
+	errVersion uint64 // version of t when err was recorded
+	propagateErrors bool
+	errorCallbacks []reactor.ErrorCallback
+
+	delayedBindings []reactor.Binding
+}
+
+// evaluates any delayed bindings of t, settling its value to the result.
+func (t *Indicator) resolve() {
+	var v interface{}
+	for _,b := range t.delayedBindings {
+		v = reactor.RunDelayed(&t.Probe, b)
+	}
+
+	if len(t.delayedBindings) > 0 {
+		reactor.Settle(t, v)
+	}
+}
+
+// SetValue sets the underlying map of t to a copy of v, as described for
+// Trigger.SetValue. Afterwards, any callbacks registered with
+// AddKeyWriteCallback are called once for each key whose value changed,
+// passing the previous and resulting key-value pairs. If a key was removed,
+// the resulting Pair is (key, <nil>). If SetValue is called during a 
+// reactor.Batch, all callbacks and bindings are held back until the Batch ends.
+func (t *Indicator) SetValue(v interface{}) {
+	m := v.(map[interface{}]interface{})
+	t.Lock.Lock()
+		prev := t.set(m)
+	t.Lock.Unlock()
+
+	if reactor.Batched(t, prev, m, t.propagate) {
+		return
+	}
+	t.propagate(prev, m)
+}
+
+// CompareAndSet sets the underlying map of t to a copy of v, as if by 
+// SetValue, but only if the version of t is still version. CompareAndSet 
+// reports whether the value was set. No callbacks are run if it was not.
+func (t *Indicator) CompareAndSet(version uint64, v interface{}) bool {
+	m := v.(map[interface{}]interface{})
+	t.Lock.Lock()
+		ok := t.version == version
+		var prev map[interface{}]interface{}
+		if ok {
+			prev = t.set(m)
+		}
+	t.Lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	if !reactor.Batched(t, prev, m, t.propagate) {
+		t.propagate(prev, m)
+	}
+	return true
+}
+
+// Update sets the underlying map of t to the result of calling f with a copy 
+// of the current map, as described for Trigger.Update, and returns a copy of 
+// the new map. Key-level write callbacks are run as if by SetValue. Delayed 
+// bindings are not evaluated. If f returns reactor.Unchanged, t is not 
+// written.
+func (t *Indicator) Update(f func(interface{}) interface{}) interface{} {
+	t.Lock.Lock()
+		next := f(copyMap(t.value))
+		if next == reactor.Unchanged {
+			result := copyMap(t.value)
+			t.Lock.Unlock()
+			return result
+		}
+		m := next.(map[interface{}]interface{})
+		prev := t.set(m)
+		result := copyMap(m)
+	t.Lock.Unlock()
+
+	if !reactor.Batched(t, prev, m, t.propagate) {
+		t.propagate(prev, m)
+	}
+	return result
+}
+
+// runs the write callbacks, bindings, and key-level write callbacks of t for 
+// a change from prev to v.
+func (t *Indicator) propagate(prev, v interface{}) {
+	t.Trigger.propagate(prev, v)
+
+	p := prev.(map[interface{}]interface{})
+	for _,pair := range changedKeys(p, v.(map[interface{}]interface{})) {
+		start := reactor.StartEvent(&t.Probe)
+		for _,c := range t.keyWriteCallbacks {
+			c(Pair{pair.Key, p[pair.Key]}, pair)
+		}
+		t.keyWatchers.Notify(Pair{pair.Key, p[pair.Key]}, pair)
+		reactor.EmitEvent(&t.Probe, reactor.Event{Kind: reactor.KeyWriteEvent, Prev: Pair{pair.Key, p[pair.Key]}, Value: pair}, start)
+	}
+}
+
+// Value returns a copy of the full map underlying t. Additionally, delayed
+// bindings associated with t will be evaluated before the value is read.
+//
+// Value calls any Callbacks registered with AddReadCallback, passing a copy of
+// the map underlying t.
+func (t *Indicator) Value() interface{} {
+	t.resolve()
+	return t.Trigger.Value()
+}
+
+// ValueVersion returns a copy of the full map underlying t, as if by Value, 
+// along with the version of t when it was read.
+func (t *Indicator) ValueVersion() (interface{}, uint64) {
+	t.resolve()
+	return t.Trigger.ValueVersion()
+}
+
+// Get returns the value associated with key, as described for Trigger.Get.
+// Delayed bindings associated with t will be evaluated before the value is
+// read.
+func (t *Indicator) Get(key interface{}) interface{} {
+	t.resolve()
+	return t.Trigger.Get(key)
+}
+
+// GetCheck returns the value associated with key and whether or not key
+// exists in t, as described for Trigger.GetCheck. Delayed bindings associated
+// with t will be evaluated before the value is read.
+func (t *Indicator) GetCheck(key interface{}) (interface{}, bool) {
+	t.resolve()
+	return t.Trigger.GetCheck(key)
+}
+
+// Keys returns an unordered slice containing all of the keys created for t.
+// Delayed bindings associated with t will be evaluated first, but no
+// ReadCallbacks will be triggered.
+func (t *Indicator) Keys() []interface{} {
+	t.resolve()
+	return t.Trigger.Keys()
+}
+
+// Values returns an unordered slice containing the values associated with
+// existing keys in t. Delayed bindings associated with t will be evaluated
+// first, but no ReadCallbacks will be triggered.
+func (t *Indicator) Values() []interface{} {
+	t.resolve()
+	return t.Trigger.Values()
+}
+
+// Size returns the size of the underlying map of t. Delayed bindings
+// associated with t will be evaluated first.
+func (t *Indicator) Size() int {
+	t.resolve()
+	return t.Trigger.Size()
+}
+
+// AddBinding binds t to i, with the value of t being determined by calling f
+// with the value of i.
+func (t *Indicator) AddBinding(i reactor.Initiator, f reactor.BindingFunc) {
+	i.AddBinder(t, f, false)
+}
+
+// AddDelayedBinding binds t to i, but the value of t is only determined when
+// it is read using Value, Get, GetCheck, Keys, Values, or Size. Because of
+// this, f is only called at the last possible moment. Consequently, this
+// binding behaves differently from the others, and any side effects will be
+// affected as such.
+func (t *Indicator) AddDelayedBinding(i reactor.Initiator, f reactor.BindingFunc) {
+	t.delayedBindings = append(t.delayedBindings, reactor.Binding{Source: i, Binder: t, F: reactor.WatchBinding(&t.Probe, reactor.DelayedBindingEvent, i, f)})
+}
+
+// AddConcurrentBinding binds t to i, with the value of t being eventually
+// determined by i. The value passed to f when it is eventually called is the
+// value of i immediately after it triggers the binding, to ensure consistency.
+func (t *Indicator) AddConcurrentBinding(i reactor.Initiator, f reactor.BindingFunc) {
+	i.AddBinder(t, reactor.QueueBinding(t, f), true)
+}
+
+// AddFallibleBinding binds t to i, with the value of t being determined by
+// calling f with the value of i. If f returns a non-nil error, t keeps its
+// previous value and the error is recorded as if by Fail.
+func (t *Indicator) AddFallibleBinding(i reactor.Initiator, f reactor.FallibleBindingFunc) {
+	i.AddBinder(t, reactor.FallibleBinding(f), false)
+}
+
+// Err returns the error recorded by the most recent failed fallible binding
+// of t. If t has been written to since that failure, either as a whole or at
+// a single key, Err returns nil.
+func (t *Indicator) Err() error {
+	t.Lock.Lock()
+	defer t.Lock.Unlock()
+	if t.version != t.errVersion {
+		return nil
+	}
+	return t.err
+}
+
+// Fail records err as the error of t without changing its value, and runs
+// any callbacks registered with AddErrorCallback. If error propagation is
+// enabled for t, err is also passed to Fail for any dependent Binders,
+// including those bound to single keys, that implement
+// reactor.FallibleBinder.
+//
+// Fail is largely intended for propagating errors between Binders, and its
+// use is discouraged outside of that. Failed fallible bindings call Fail
+// automatically.
+func (t *Indicator) Fail(err error) {
+	t.Lock.Lock()
+		t.err = err
+		t.errVersion = t.version
+		propagate := t.propagateErrors
+		binders := make([]reactor.Binder, 0, len(t.bindings)+len(t.keyBindings))
+		for _,b := range t.bindings {
+			binders = append(binders, b.Binder)
+		}
+		for _,b := range t.keyBindings {
+			binders = append(binders, b.Binder)
+		}
+	t.Lock.Unlock()
+
+	for _,c := range t.errorCallbacks {
+		c(err)
+	}
+
+	if !propagate {
+		return
+	}
+	for _,b := range binders {
+		if f,ok := b.(reactor.FallibleBinder); ok {
+			f.Fail(err)
+		}
+	}
+}
+
+// PropagateErrors sets whether errors recorded by t are passed on to the
+// Binders that depend on t. Errors are not propagated by default.
+func (t *Indicator) PropagateErrors(propagate bool) {
+	t.Lock.Lock()
+		t.propagateErrors = propagate
+	t.Lock.Unlock()
+}
+
+// AddErrorCallback adds a callback that will be run when a fallible binding
+// of t fails, or an error is propagated to t.
+func (t *Indicator) AddErrorCallback(e reactor.ErrorCallback) {
+	t.errorCallbacks = append(t.errorCallbacks, e)
+}
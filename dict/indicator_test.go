@@ -0,0 +1,193 @@
+package dict
+
+import (
+	"errors"
+	"testing"
+	"reflect"
+	"strings"
+
+	"github.com/KellenWatt/reactor"
+)
+
+func upper(v interface{}) interface{} {
+	out := make(map[interface{}]interface{})
+	for k,x := range v.(map[interface{}]interface{}) {
+		out[k] = strings.ToUpper(x.(string))
+	}
+	return out
+}
+
+func TestIndicatorImplementsBinder(t *testing.T) {
+	var _ reactor.Binder = &Indicator{}
+	var _ reactor.FallibleBinder = &Indicator{}
+}
+
+func TestIndicatorAddBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+
+	ind.AddBinding(&trigger, upper)
+	trigger.SetValue(map[interface{}]interface{}{"a": "x", "b": "y"})
+
+	want := map[interface{}]interface{}{"a": "X", "b": "Y"}
+	if !reflect.DeepEqual(ind.Value(), want) {
+		t.Fatalf("Expected %v; got %v", want, ind.Value())
+	}
+
+	if ind.Get("a") != "X" {
+		t.Fatalf("Expected X for key a; got %v", ind.Get("a"))
+	}
+}
+
+func TestIndicatorKeyEvents(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	var written []Pair
+	var prevs []Pair
+
+	ind.AddBinding(&trigger, upper)
+	trigger.SetValue(map[interface{}]interface{}{"a": "x", "b": "y"})
+
+	ind.AddKeyWriteCallback(func(prev, v interface{}) {
+		prevs = append(prevs, prev.(Pair))
+		written = append(written, v.(Pair))
+	})
+
+	trigger.SetValue(map[interface{}]interface{}{"a": "x", "b": "z", "c": "w"})
+	trigger.SetValue(map[interface{}]interface{}{"a": "x", "b": "z"})
+
+	want := map[Pair]Pair{{"b", "Y"}: {"b", "Z"}, {"c", nil}: {"c", "W"}}
+	if len(written) != 3 {
+		t.Fatalf("Expected 3 key-level events; got %v", written)
+	}
+	for i,p := range written[:2] {
+		if want[prevs[i]] != p {
+			t.Fatalf("Unexpected key-level event %v -> %v", prevs[i], p)
+		}
+	}
+	if prevs[2] != (Pair{"c", "W"}) || written[2] != (Pair{"c", nil}) {
+		t.Fatalf("Expected removal of c; got %v -> %v", prevs[2], written[2])
+	}
+}
+
+func TestIndicatorKeyBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	var entry reactor.Indicator
+	var count int
+
+	ind.AddBinding(&trigger, upper)
+	entry.AddBinding(ind.Entry("a"), func(v interface{}) interface{} {
+		count += 1
+		return v.(Pair).Value
+	})
+
+	trigger.SetValue(map[interface{}]interface{}{"a": "x"})
+	trigger.SetValue(map[interface{}]interface{}{"a": "x", "b": "y"})
+	if count != 1 || entry.Value() != "X" {
+		t.Fatalf("Expected binding to run once with X; ran %d times with %v", count, entry.Value())
+	}
+}
+
+func TestIndicatorDelayedBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	var count int
+
+	ind.AddDelayedBinding(&trigger, func(v interface{}) interface{} {
+		count += 1
+		return upper(v)
+	})
+
+	trigger.SetValue(map[interface{}]interface{}{"a": "x"})
+	if count != 0 {
+		t.Fatal("Delayed binding executed without a read")
+	}
+
+	v,ok := ind.GetCheck("a")
+	if !ok || v != "X" || count != 1 {
+		t.Fatalf("Expected X after 1 evaluation; got %v after %d", v, count)
+	}
+}
+
+func TestIndicatorDelayedBindingUnchanged(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	bad := errors.New("empty")
+	ind.AddDelayedBinding(&trigger, reactor.FallibleBinding(func(v interface{}) (interface{}, error) {
+		switch len(v.(map[interface{}]interface{})) {
+		case 0:
+			return nil, bad
+		case 1:
+			return reactor.Unchanged, nil
+		}
+		return v, nil
+	}))
+
+	trigger.SetValue(map[interface{}]interface{}{"a": 1, "b": 2})
+	ind.Value()
+	trigger.SetValue(map[interface{}]interface{}{"a": 3})
+	if ind.Get("a") != 1 {
+		t.Fatalf("Expected Unchanged to keep a=1; got %v", ind.Value())
+	}
+	trigger.SetValue(map[interface{}]interface{}{})
+	if ind.Get("a") != 1 || ind.Err() != bad {
+		t.Fatalf("Expected failure to keep a=1 and record its error; got %v, %v", ind.Value(), ind.Err())
+	}
+}
+
+func TestIndicatorConcurrentBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	done := make(chan bool)
+
+	ind.AddConcurrentBinding(&trigger, upper)
+	ind.AddWriteCallback(func(prev, v interface{}) {
+		done <- true
+	})
+
+	trigger.SetValue(map[interface{}]interface{}{"a": "x"})
+	<-done
+
+	if ind.Get("a") != "X" {
+		t.Fatalf("Expected X; got %v", ind.Get("a"))
+	}
+}
+
+func TestIndicatorFallibleBinding(t *testing.T) {
+	var trigger reactor.Trigger
+	var ind Indicator
+	var entry reactor.Indicator
+	var errs []error
+	bad := errors.New("not a map")
+
+	ind.AddFallibleBinding(&trigger, func(v interface{}) (interface{}, error) {
+		m,ok := v.(map[interface{}]interface{})
+		if !ok {
+			return nil, bad
+		}
+		return m, nil
+	})
+	ind.AddErrorCallback(func(err error) {
+		errs = append(errs, err)
+	})
+	entry.AddBinding(ind.Entry("a"), reactor.TrivialBinding)
+	ind.PropagateErrors(true)
+
+	trigger.SetValue(map[interface{}]interface{}{"a": 1})
+	trigger.SetValue("a")
+	if ind.Get("a") != 1 {
+		t.Fatalf("Failed binding should keep previous value; got %v", ind.Value())
+	}
+	if ind.Err() != bad || len(errs) != 1 {
+		t.Fatalf("Expected one error; got %v with %d callbacks", ind.Err(), len(errs))
+	}
+	if entry.Err() != bad {
+		t.Fatalf("Expected error to propagate to key binding; got %v", entry.Err())
+	}
+
+	ind.Set("b", 2)
+	if ind.Err() != nil {
+		t.Fatalf("Expected error to be cleared by a write; got %v", ind.Err())
+	}
+}
@@ -0,0 +1,127 @@
+package dict
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+)
+
+// KeyDecoder converts a key read from JSON back into the key it was encoded
+// from. It is used by Trigger.UnmarshalJSON to recover keys that are not
+// strings.
+type KeyDecoder func(string) (interface{}, error)
+
+// EncodeKey encodes k as a string, as used for JSON object keys by
+// Trigger.MarshalJSON. Strings are used as-is, and values that implement
+// encoding.TextMarshaler are encoded with MarshalText. Anything else is
+// formatted as if by fmt.Sprint.
+func EncodeKey(k interface{}) (string, error) {
+	switch k := k.(type) {
+	case string:
+		return k, nil
+	case encoding.TextMarshaler:
+		b,err := k.MarshalText()
+		return string(b), err
+	default:
+		return fmt.Sprint(k), nil
+	}
+}
+
+// EncodeMap returns a copy of m with every key encoded by EncodeKey, so that
+// it can be encoded as a JSON object. If two keys encode to the same string,
+// only one of them is kept.
+func EncodeMap(m map[interface{}]interface{}) (map[string]interface{}, error) {
+	n := make(map[string]interface{}, len(m))
+	for k,v := range m {
+		key,err := EncodeKey(k)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = v
+	}
+	return n, nil
+}
+
+// encodes m as a JSON object, with keys encoded by EncodeKey.
+func marshalMap(m map[interface{}]interface{}) ([]byte, error) {
+	n,err := EncodeMap(m)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(n)
+}
+
+// SetKeyDecoder sets the KeyDecoder used by t to convert keys when decoding
+// JSON. If d is nil, which is the default, keys are left as strings.
+func (t *Trigger) SetKeyDecoder(d KeyDecoder) {
+	t.Lock.Lock()
+		t.keyDecoder = d
+	t.Lock.Unlock()
+}
+
+// decodes data as a JSON object, converting its keys with the KeyDecoder of t.
+func (t *Trigger) unmarshalMap(data []byte) (map[interface{}]interface{}, error) {
+	var n map[string]interface{}
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+
+	t.Lock.Lock()
+		decode := t.keyDecoder
+	t.Lock.Unlock()
+
+	m := make(map[interface{}]interface{}, len(n))
+	for k,v := range n {
+		var key interface{} = k
+		if decode != nil {
+			var err error
+			if key,err = decode(k); err != nil {
+				return nil, err
+			}
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding the value of t as a JSON
+// object. Since JSON object keys must be strings, string keys are used as-is,
+// keys that implement encoding.TextMarshaler are encoded with MarshalText, and
+// any other key is formatted as if by fmt.Sprint. If two keys format to the
+// same string, only one of them is kept.
+//
+// The value is read as if by Value, so any read callbacks are run.
+func (t *Trigger) MarshalJSON() ([]byte, error) {
+	return marshalMap(t.Value().(map[interface{}]interface{}))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding data as a JSON object
+// and setting t to the result as if by SetValue, so any callbacks and bindings
+// are run. Keys are converted by the KeyDecoder set with SetKeyDecoder, and
+// values are decoded as they would be into an interface{}.
+func (t *Trigger) UnmarshalJSON(data []byte) error {
+	m,err := t.unmarshalMap(data)
+	if err != nil {
+		return err
+	}
+	t.SetValue(m)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, as described for Trigger.MarshalJSON.
+// Any delayed bindings are evaluated first.
+func (t *Indicator) MarshalJSON() ([]byte, error) {
+	return marshalMap(t.Value().(map[interface{}]interface{}))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, as described for
+// Trigger.UnmarshalJSON. Key callbacks are run for each key whose value
+// changed.
+func (t *Indicator) UnmarshalJSON(data []byte) error {
+	m,err := t.unmarshalMap(data)
+	if err != nil {
+		return err
+	}
+	t.SetValue(m)
+	return nil
+}
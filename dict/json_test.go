@@ -0,0 +1,74 @@
+package dict
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestTriggerJSON(t *testing.T) {
+	var d Trigger
+	d.Set("name", "reactor")
+	d.Set(2, true)
+
+	data,err := json.Marshal(&d)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != `{"2":true,"name":"reactor"}` {
+		t.Fatalf("Expected stringified keys; got %s", data)
+	}
+
+	var copied Trigger
+	if err := json.Unmarshal(data, &copied); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if copied.Get("2") != true || copied.Get("name") != "reactor" {
+		t.Fatalf("Expected keys to be left as strings; got %v", copied.Value())
+	}
+}
+
+func TestTriggerKeyDecoder(t *testing.T) {
+	var d Trigger
+	var changed []interface{}
+	d.SetKeyDecoder(func(k string) (interface{}, error) {
+		return strconv.Atoi(k)
+	})
+	d.AddKeyBinder(1, nil, func(v interface{}) interface{} {
+		changed = append(changed, v.(Pair).Key)
+		return nil
+	}, true)
+
+	if err := json.Unmarshal([]byte(`{"1":"one","2":"two"}`), &d); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if d.Get(1) != "one" || d.Get(2) != "two" {
+		t.Fatalf("Expected int keys; got %v", d.Value())
+	}
+	if len(changed) != 1 || changed[0] != 1 {
+		t.Fatalf("Expected key binding for 1 to run through SetValue; got %v", changed)
+	}
+
+	if err := json.Unmarshal([]byte(`{"x":1}`), &d); err == nil {
+		t.Fatal("Expected key decoder error")
+	}
+	if d.Size() != 2 {
+		t.Fatalf("Failed decode should not change value; got %v", d.Value())
+	}
+}
+
+func TestIndicatorUnmarshalJSON(t *testing.T) {
+	var d Indicator
+	var keys []interface{}
+	d.SetValue(map[interface{}]interface{}{"a": 1.0, "b": 2.0})
+	d.AddKeyWriteCallback(func(prev, v interface{}) {
+		keys = append(keys, v.(Pair).Key)
+	})
+
+	if err := json.Unmarshal([]byte(`{"a":1,"b":3}`), &d); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "b" {
+		t.Fatalf("Expected key callback only for b; got %v", keys)
+	}
+}
@@ -8,6 +8,7 @@
 package dict
 
 import (
+	"context"
 	"sync"
 
 	"github.com/KellenWatt/reactor"
@@ -42,7 +43,9 @@ type Pair struct {
 // scope of this package.
 type Trigger struct {
 	Lock sync.Mutex
+	reactor.Probe
 	value map[interface{}]interface{}
+	version uint64
 
 	readCallbacks []reactor.ReadCallback
     writeCallbacks []reactor.WriteCallback
@@ -50,7 +53,26 @@ type Trigger struct {
     keyReadCallbacks []reactor.ReadCallback
     keyWriteCallbacks []reactor.WriteCallback
 
+    watchers reactor.Watchers
+    keyWatchers reactor.Watchers
+
     bindings []reactor.Binding
+    keyBindings []keyBinding
+
+    keyDecoder KeyDecoder
+}
+
+// collects the key bindings of t affected by the changes in pairs.
+func (t *Trigger) affected(pairs ...Pair) []pendingBinding {
+	var pending []pendingBinding
+	for _,p := range pairs {
+		for _,b := range t.keyBindings {
+			if b.matches(p.Key) {
+				pending = append(pending, pendingBinding{b.Binding, p})
+			}
+		}
+	}
+	return pending
 }
 
 // Value returns a copy of the full slice underlying t.
@@ -58,15 +80,25 @@ type Trigger struct {
 // Value calls any Callbacks registerd with AddReadCallback, passing a copy of
 // the slice underlying t.
 func (t *Trigger) Value() interface{} {
+	m,_ := t.ValueVersion()
+	return m
+}
+
+// ValueVersion returns a copy of the full map underlying t, as if by Value, 
+// along with the version of t when it was read.
+func (t *Trigger) ValueVersion() (interface{}, uint64) {
 	t.Lock.Lock()
 		m := copyMap(t.value)
+		version := t.version
 	t.Lock.Unlock()
 
+	start := reactor.StartEvent(&t.Probe)
 	for _,c := range t.readCallbacks {
 		c(m)
 	}
+	reactor.EmitEvent(&t.Probe, reactor.Event{Kind: reactor.ReadEvent, Value: m}, start)
 
-	return m
+	return m, version
 }
 
 // SetValue sets the underlying map of t to a copy of v. v is tested to 
@@ -74,24 +106,107 @@ func (t *Trigger) Value() interface{} {
 // value is not the proper type, SetValue panics.
 //
 // SetValue calls any callbacks registered with AddWriteCallback, passing
-// a copy of the previous map and v (as an map[interface{}]interface{}).
+// a copy of the previous map and v (as an map[interface{}]interface{}). Any 
+// key-level bindings are run for each key whose value was changed. If 
+// SetValue is called during a reactor.Batch, callbacks and bindings are held 
+// back until the Batch ends.
 func (t *Trigger) SetValue(v interface{}) {
-	t.Lock.Lock() 
-		m := v.(map[interface{}]interface{})
-		prev := t.value
-		t.value = copyMap(m)
+	m := v.(map[interface{}]interface{})
+	t.Lock.Lock()
+		prev := t.set(m)
 	t.Lock.Unlock()
 
+	if reactor.Batched(t, prev, m, t.propagate) {
+		return
+	}
+	t.propagate(prev, m)
+}
+
+// sets the underlying map of t to a copy of m, returning the previous map. 
+// Must be called while holding t.Lock.
+func (t *Trigger) set(m map[interface{}]interface{}) map[interface{}]interface{} {
+	prev := t.value
+	t.value = copyMap(m)
+	t.version++
+	return prev
+}
+
+// Version returns the version of t, which is increased every time t is 
+// written to, whether by SetValue or a key-level method.
+func (t *Trigger) Version() uint64 {
+	t.Lock.Lock()
+		version := t.version
+	t.Lock.Unlock()
+	return version
+}
+
+// CompareAndSet sets the underlying map of t to a copy of v, as if by 
+// SetValue, but only if the version of t is still version. CompareAndSet 
+// reports whether the value was set. No callbacks are run if it was not.
+func (t *Trigger) CompareAndSet(version uint64, v interface{}) bool {
+	m := v.(map[interface{}]interface{})
+	t.Lock.Lock()
+		ok := t.version == version
+		var prev map[interface{}]interface{}
+		if ok {
+			prev = t.set(m)
+		}
+	t.Lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	if !reactor.Batched(t, prev, m, t.propagate) {
+		t.propagate(prev, m)
+	}
+	return true
+}
+
+// Update sets the underlying map of t to the result of calling f with a copy 
+// of the current map, and returns a copy of the new map. f must return a 
+// map[interface{}]interface{}, or Update panics. f is called while holding 
+// t.Lock, so no other write can happen between reading and writing, but f must 
+// not access t itself. Callbacks and bindings are run as if by SetValue, after
+// the lock is released. No read callbacks are run. If f returns 
+// reactor.Unchanged, t is not written, no callbacks are run, and a copy of the 
+// current map is returned.
+func (t *Trigger) Update(f func(interface{}) interface{}) interface{} {
+	t.Lock.Lock()
+		next := f(copyMap(t.value))
+		if next == reactor.Unchanged {
+			result := copyMap(t.value)
+			t.Lock.Unlock()
+			return result
+		}
+		m := next.(map[interface{}]interface{})
+		prev := t.set(m)
+		result := copyMap(m)
+	t.Lock.Unlock()
+
+	if !reactor.Batched(t, prev, m, t.propagate) {
+		t.propagate(prev, m)
+	}
+	return result
+}
+
+// runs the write callbacks and bindings of t for a change from prev to v.
+func (t *Trigger) propagate(prev, v interface{}) {
+	pending := t.affected(changedKeys(prev.(map[interface{}]interface{}), 
+	                                  v.(map[interface{}]interface{}))...)
+
+	start := reactor.StartEvent(&t.Probe)
 	for _,c := range t.writeCallbacks {
-		c(prev, m)
+		c(prev, v)
 	}
 
+	t.watchers.Notify(prev, v)
+
 	for _,b := range t.bindings {
-		val := b.F(v)
-        if !b.Concurrent {
-            b.Binder.SetValue(val)
-        }
+		reactor.RunBinding(&t.Probe, b, v)
 	}
+
+	runPending(&t.Probe, pending)
+	reactor.EmitEvent(&t.Probe, reactor.Event{Kind: reactor.WriteEvent, Prev: prev, Value: v}, start)
 }
 
 // AddBinder adds a Binder to be executed when the value of t changes. If 
@@ -100,7 +215,7 @@ func (t *Trigger) SetValue(v interface{}) {
 //
 // Note that Bindings are not be executed for individual key-value changes. 
 // As such, any Binder that is relying on such a change would best be served 
-// by using a delayed binding.
+// by binding to an Entry or Entries, or by using a delayed binding.
 //
 // AddBinder is largely intended for use in implementing Binders, and its 
 // use is heavily discouraged outside of that. If concurrent is true outside 
@@ -108,18 +223,61 @@ func (t *Trigger) SetValue(v interface{}) {
 // If concurrent is false, this will have exactly the same effect as 
 // Binder.AddBinding(), which is the preferred method of creating bindings.
 func (t *Trigger) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
-    t.bindings = append(t.bindings, reactor.Binding{t, b, f, concurrent})
+    t.bindings = append(t.bindings, reactor.Binding{Source: t, Binder: b, F: reactor.WatchBinding(&t.Probe, reactor.BindingEvent, b, f), Concurrent: concurrent})
+}
+
+// AddKeyBinder adds a Binder to be executed when the value associated with 
+// key changes, whether by Set, Delete, or SetValue. The value passed to f is 
+// the resulting key-value pair as a Pair struct. If the key was deleted, the 
+// Pair is (key, <nil>).
+//
+// Like AddBinder, AddKeyBinder is largely intended for use in implementing 
+// Binders. Binding to the result of Entry is the preferred method of creating 
+// key-level bindings.
+func (t *Trigger) AddKeyBinder(key interface{}, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	binding := reactor.Binding{Source: t.Entry(key), Binder: b, F: reactor.WatchBinding(&t.Probe, reactor.BindingEvent, b, f), Concurrent: concurrent}
+	t.keyBindings = append(t.keyBindings, keyBinding{[]interface{}{key}, binding})
+}
+
+// AddKeysBinder adds a Binder to be executed when the value associated with 
+// any of keys changes. If keys is empty, the Binder is executed for any 
+// key-level change. f is run once for each affected key, and is passed the 
+// same as it would be by AddKeyBinder.
+//
+// Like AddBinder, AddKeysBinder is largely intended for use in implementing 
+// Binders. Binding to the result of Entries is the preferred method of 
+// creating key-level bindings.
+func (t *Trigger) AddKeysBinder(keys []interface{}, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	keys = append([]interface{}(nil), keys...)
+	binding := reactor.Binding{Source: t.Entries(keys...), Binder: b, F: reactor.WatchBinding(&t.Probe, reactor.BindingEvent, b, f), Concurrent: concurrent}
+	t.keyBindings = append(t.keyBindings, keyBinding{keys, binding})
+}
+
+// Watch returns a channel that receives a Change for every write to t using 
+// SetValue, until ctx is cancelled. The values of each Change are the same as 
+// those passed to callbacks registered with AddWriteCallback. The channel is 
+// closed once ctx is cancelled.
+func (t *Trigger) Watch(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return t.watchers.Add(ctx, mode)
+}
+
+// WatchKey returns a channel that receives a Change for every key-level write 
+// to t, until ctx is cancelled. The values of each Change are the same Pair 
+// structs passed to callbacks registered with AddKeyWriteCallback. The channel 
+// is closed once ctx is cancelled.
+func (t *Trigger) WatchKey(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return t.keyWatchers.Add(ctx, mode)
 }
 
 // AddReadCallback adds a callback that will be run when t is read using Value
 func (t *Trigger) AddReadCallback(r reactor.ReadCallback) {
-    t.readCallbacks = append(t.readCallbacks, r)
+    t.readCallbacks = append(t.readCallbacks, reactor.WatchRead(&t.Probe, reactor.ReadEvent, r))
 }
 
 // AddWriteCallback adds a callback that will be run when t is written to 
 // using SetValue.
 func (t *Trigger) AddWriteCallback(w reactor.WriteCallback) {
-    t.writeCallbacks = append(t.writeCallbacks, w)
+    t.writeCallbacks = append(t.writeCallbacks, reactor.WatchWrite(&t.Probe, reactor.WriteEvent, w))
 }
 
 // Get returns the value associated with key. If key does not exist in t, 
@@ -139,9 +297,7 @@ func (t *Trigger) Get(key interface{}) interface{} {
 		v := t.value[key]
 	t.Lock.Unlock()
 
-	for _,c := range t.keyReadCallbacks {
-		c(Pair{key,v})
-	}
+	t.keyRead(Pair{key, v})
 
 	return v
 }
@@ -163,13 +319,20 @@ func (t *Trigger) GetCheck(key interface{}) (interface{}, bool) {
 		v,exists := t.value[key]
 	t.Lock.Unlock()
 
-	for _,c := range t.keyReadCallbacks {
-		c(Pair{key, v})
-	}
+	t.keyRead(Pair{key, v})
 
 	return v, exists
 }
 
+// runs the key-level read callbacks of t for a read of p.
+func (t *Trigger) keyRead(p Pair) {
+	start := reactor.StartEvent(&t.Probe)
+	for _,c := range t.keyReadCallbacks {
+		c(p)
+	}
+	reactor.EmitEvent(&t.Probe, reactor.Event{Kind: reactor.KeyReadEvent, Value: p}, start)
+}
+
 // Set updates the value associated with key to value. If key does not exist 
 // in t, Set creates the key with a value of value.
 //
@@ -186,11 +349,11 @@ func (t *Trigger) Set(key, value interface{}) {
 		}
 		prev := t.value[key]
 		t.value[key] = value
+		t.version++
+		pending := t.affected(Pair{key, value})
 	t.Lock.Unlock()
 
-	for _,c := range t.keyWriteCallbacks {
-		c(Pair{key, prev}, Pair{key, value})
-	}
+	t.keyWritten(Pair{key, prev}, Pair{key, value}, pending)
 }
 
 // Delete removes key from t. If key does not exist in t, delete changes 
@@ -202,19 +365,40 @@ func (t *Trigger) Set(key, value interface{}) {
 // Any callbacks registered with AddKeyWriteCallback will be called, passing
 // the previous and resulting key-value pair to the callback as a Pair struct.
 // The resulting Pair is always (key, <nil>). If the key did not exist 
-// previously, the previous Pair is (key, <nil>).
+// previously, the previous Pair is (key, <nil>), and no key-level bindings 
+// are run.
 func (t *Trigger) Delete(key interface{}) {
 	t.Lock.Lock()
 		if t.value == nil {
 			t.value = make(map[interface{}]interface{})
 		}
-		prev := t.value[key]
+		prev,existed := t.value[key]
 		delete(t.value, key)
+		t.version++
+		var pending []pendingBinding
+		if existed {
+			pending = t.affected(Pair{key, nil})
+		}
 	t.Lock.Unlock()
 
-	for _,c := range t.keyWriteCallbacks {
-		c(Pair{key, prev}, Pair{key, nil})
-	}
+	t.keyWritten(Pair{key, prev}, Pair{key, nil}, pending)
+}
+
+// runs the key-level write callbacks and watchers of t for a change from prev 
+// to v, followed by the pending key bindings. During a reactor.Batch, they are 
+// held back until the Batch ends.
+func (t *Trigger) keyWritten(prev, v Pair, pending []pendingBinding) {
+	reactor.Defer(func() {
+		start := reactor.StartEvent(&t.Probe)
+		for _,c := range t.keyWriteCallbacks {
+			c(prev, v)
+		}
+
+		t.keyWatchers.Notify(prev, v)
+
+		runPending(&t.Probe, pending)
+		reactor.EmitEvent(&t.Probe, reactor.Event{Kind: reactor.KeyWriteEvent, Prev: prev, Value: v}, start)
+	})
 }
 
 // Keys returns an unordered slice containing all of the keys created for t.
@@ -262,12 +446,12 @@ func (t *Trigger) Size() int {
 // key-level read events. The value passed to the callback will be a Pair 
 // struct containing the key-value pair being read.
 func (t *Trigger) AddKeyReadCallback(r reactor.ReadCallback) {
-	t.keyReadCallbacks = append(t.keyReadCallbacks, r)
+	t.keyReadCallbacks = append(t.keyReadCallbacks, reactor.WatchRead(&t.Probe, reactor.KeyReadEvent, r))
 }
 
 // AddKeyWriteCallback registers a WriteCallback that will be triggered any 
 // key-level write events. The values passed to the callback will be Pair 
 // structs containing the previous and resluting key-value pairs.
 func (t *Trigger) AddKeyWriteCallback(w reactor.WriteCallback) {
-	t.keyWriteCallbacks = append(t.keyWriteCallbacks, w)
+	t.keyWriteCallbacks = append(t.keyWriteCallbacks, reactor.WatchWrite(&t.Probe, reactor.KeyWriteEvent, w))
 }
@@ -1,7 +1,10 @@
 package dict
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 	"reflect"
 	
 	"github.com/KellenWatt/reactor"
@@ -519,3 +522,113 @@ func TestTriggerKeyWriteCallback(t *testing.T) {
         t.Fatalf("Expected count to be %d after %d iterations; got %d", len(m), len(m), count)
     }
 }
+
+func TestTriggerVersion(t *testing.T) {
+	var trigger Trigger
+
+	trigger.SetValue(initMap())
+	trigger.Set(1, "uno")
+	trigger.Delete(2)
+	if trigger.Version() != 3 {
+		t.Fatalf("Expected version 3 after three writes; got %d", trigger.Version())
+	}
+
+	if trigger.CompareAndSet(2, map[interface{}]interface{}{}) {
+		t.Fatal("CompareAndSet succeeded with stale version")
+	}
+	if !trigger.CompareAndSet(3, map[interface{}]interface{}{}) || trigger.Size() != 0 {
+		t.Fatal("CompareAndSet failed with current version")
+	}
+}
+
+func TestTriggerUpdate(t *testing.T) {
+	var trigger Trigger
+	var keys []interface{}
+	trigger.SetValue(initMap())
+	trigger.AddKeyBinder(1, &reactor.Indicator{}, func(v interface{}) interface{} {
+		keys = append(keys, v.(Pair).Key)
+		return v
+	}, false)
+
+	trigger.Update(func(v interface{}) interface{} {
+		m := v.(map[interface{}]interface{})
+		m[1] = "uno"
+		return m
+	})
+
+	if trigger.Get(1) != "uno" || len(keys) != 1 {
+		t.Fatalf("Expected key 1 to be updated once; got %v and %v", trigger.Get(1), keys)
+	}
+}
+
+func TestTriggerWatchKey(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	keys := trigger.WatchKey(ctx, reactor.Buffered(10))
+
+	trigger.Set("a", 1)
+	trigger.Delete("a")
+
+	want := []reactor.Change{
+		{Prev: Pair{"a", nil}, Value: Pair{"a", 1}},
+		{Prev: Pair{"a", 1}, Value: Pair{"a", nil}},
+	}
+	for _,w := range want {
+		if c := <-keys; c != w {
+			t.Fatalf("Expected %v; got %v", w, c)
+		}
+	}
+}
+
+func TestTriggerTraceKeyEvents(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var events []reactor.Event
+	trigger.SetName("config")
+	ind.SetName("port")
+	trigger.SetTracer(reactor.TracerFunc(func(e reactor.Event) {
+		events = append(events, e)
+	}))
+	trigger.AddKeyBinder("port", &ind, func(v interface{}) interface{} {
+		return v.(Pair).Value
+	}, false)
+
+	trigger.Set("port", 80)
+	trigger.Get("port")
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events; got %v", events)
+	}
+	if e := events[0]; e.Kind != reactor.BindingEvent || e.Target != "port" || e.Value != 80 {
+		t.Fatalf("Unexpected binding event %+v", e)
+	}
+	if e := events[1]; e.Kind != reactor.KeyWriteEvent || e.Value != (Pair{"port", 80}) {
+		t.Fatalf("Unexpected key write event %+v", e)
+	}
+	if e := events[2]; e.Kind != reactor.KeyReadEvent || e.Source != "config" {
+		t.Fatalf("Unexpected key read event %+v", e)
+	}
+}
+
+func TestTriggerWatchdogKeyCallbackSite(t *testing.T) {
+	var calls []reactor.SlowCall
+	reactor.SetWatchdog(&reactor.Watchdog{Threshold: time.Millisecond, Report: func(s reactor.SlowCall) {
+		calls = append(calls, s)
+	}})
+	defer reactor.SetWatchdog(nil)
+
+	var trigger Trigger
+	trigger.AddKeyWriteCallback(func(prev, v interface{}) {
+		time.Sleep(5*time.Millisecond)
+	})
+	trigger.Set("k", 1)
+
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 slow call; got %v", calls)
+	}
+	if c := calls[0]; c.Kind != reactor.KeyWriteEvent || !strings.HasSuffix(c.File, "dict/trigger_test.go") {
+		t.Fatalf("Expected the site in the test; got %+v", c)
+	}
+}
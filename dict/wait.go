@@ -0,0 +1,55 @@
+package dict
+
+import (
+	"context"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// waitBuffer is the number of changes queued for WaitForKey while it is
+// evaluating its predicate.
+const waitBuffer = 16
+
+// WaitForKey blocks until a key-value pair of t satisfies pred, and returns
+// that pair. Every pair currently in t is checked first, in no particular
+// order. After that, pred is called with the resulting Pair of every
+// key-level write to t, and of every key changed by SetValue, until one
+// satisfies it. Deleted keys are passed as (key, <nil>). If ctx is cancelled
+// or times out first, WaitForKey returns an empty Pair and the error from ctx.
+func WaitForKey(ctx context.Context, t *Trigger, pred func(Pair) bool) (Pair, error) {
+	ctx,cancel := context.WithCancel(ctx)
+	defer cancel()
+	// subscribe before checking, so no write is missed in between
+	values := t.Watch(ctx, reactor.Buffered(waitBuffer))
+	keys := t.WatchKey(ctx, reactor.Buffered(waitBuffer))
+
+	for k,v := range t.Value().(map[interface{}]interface{}) {
+		if pred(Pair{k, v}) {
+			return Pair{k, v}, nil
+		}
+	}
+
+	for {
+		select {
+		case c,ok := <-values:
+			if !ok {
+				return Pair{}, ctx.Err()
+			}
+			prev := c.Prev.(map[interface{}]interface{})
+			for _,p := range changedKeys(prev, c.Value.(map[interface{}]interface{})) {
+				if pred(p) {
+					return p, nil
+				}
+			}
+		case c,ok := <-keys:
+			if !ok {
+				return Pair{}, ctx.Err()
+			}
+			if p := c.Value.(Pair); pred(p) {
+				return p, nil
+			}
+		case <-ctx.Done():
+			return Pair{}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package dict
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForKeyCurrent(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(initMap())
+
+	p,err := WaitForKey(context.Background(), &trigger, func(p Pair) bool {
+		return p.Key == 3
+	})
+	if err != nil || p != (Pair{3, "three"}) {
+		t.Fatalf("Expected (%v, <nil>); got (%v, %v)", Pair{3, "three"}, p, err)
+	}
+}
+
+func TestWaitForKey(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		trigger.Set("status", "starting")
+		trigger.SetValue(map[interface{}]interface{}{"status": "ready"})
+	}()
+
+	p,err := WaitForKey(ctx, &trigger, func(p Pair) bool {
+		return p == Pair{"status", "ready"}
+	})
+	if err != nil || p != (Pair{"status", "ready"}) {
+		t.Fatalf("Expected (%v, <nil>); got (%v, %v)", Pair{"status", "ready"}, p, err)
+	}
+}
+
+func TestWaitForKeyDeleted(t *testing.T) {
+	var trigger Trigger
+	trigger.Set("lock", true)
+	ctx,cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go trigger.Delete("lock")
+
+	p,err := WaitForKey(ctx, &trigger, func(p Pair) bool {
+		return p.Key == "lock" && p.Value == nil
+	})
+	if err != nil || p != (Pair{"lock", nil}) {
+		t.Fatalf("Expected (%v, <nil>); got (%v, %v)", Pair{"lock", nil}, p, err)
+	}
+}
+
+func TestWaitForKeyTimeout(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_,err := WaitForKey(ctx, &trigger, func(p Pair) bool {
+		return false
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v; got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,59 @@
+package reactor
+
+// outcome is a result of a BindingFunc that is not set as the value of its
+// Binder. A nil err leaves the Binder unchanged.
+type outcome struct {
+	err error
+}
+
+func (o outcome) String() string {
+	if o.err == nil {
+		return "unchanged"
+	}
+	return "failed: " + o.err.Error()
+}
+
+// Unchanged can be returned by a BindingFunc to leave its Binder unchanged,
+// rather than set it to the result. No callbacks or bindings of the Binder are
+// run. Unchanged is understood by every Initiator and Binder in this module, and
+// by any that runs its bindings with RunBinding and settles its delayed
+// bindings with Settle.
+var Unchanged interface{} = outcome{}
+
+// FallibleBinding returns a BindingFunc that calls f and returns its result.
+// If f fails, the returned BindingFunc instead returns a result that leaves
+// its Binder unchanged, and records the error with Fail, if the Binder
+// implements FallibleBinder. Like Unchanged, this is understood by any
+// Initiator that runs its bindings with RunBinding.
+//
+// FallibleBinding is intended for use in implementing AddFallibleBinding for
+// Binders outside of this package, and its use is discouraged otherwise.
+func FallibleBinding(f FallibleBindingFunc) BindingFunc {
+	return func(v interface{}) interface{} {
+		val,err := f(v)
+		if err != nil {
+			return outcome{err}
+		}
+		return val
+	}
+}
+
+// Settle sets b to v, the result of a BindingFunc, unless v is Unchanged or a
+// failure returned by a FallibleBinding. In that case, b is left unchanged,
+// and any error is recorded with Fail, if b implements FallibleBinder.
+//
+// Settle is intended for use in implementing Binders outside of this package,
+// and its use is discouraged otherwise.
+func Settle(b Binder, v interface{}) {
+	o,ok := v.(outcome)
+	if !ok {
+		b.SetValue(v)
+		return
+	}
+	if o.err == nil {
+		return
+	}
+	if f,ok := b.(FallibleBinder); ok {
+		f.Fail(o.err)
+	}
+}
@@ -0,0 +1,23 @@
+package reactor
+
+import (
+	"context"
+)
+
+// BindChannel sets the value of i to every value received from ch, in the 
+// order they are received, until ch is closed or ctx is cancelled. BindChannel 
+// blocks until then, so it will usually be run in its own goroutine. If ctx 
+// was cancelled, its error is returned. Otherwise, the error is nil.
+func BindChannel(ctx context.Context, ch <-chan interface{}, i Initiator) error {
+	for {
+		select {
+		case v,ok := <-ch:
+			if !ok {
+				return nil
+			}
+			i.SetValue(v)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package reactor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBindChannel(t *testing.T) {
+	var trigger Trigger
+	var values []interface{}
+	ch := make(chan interface{}, 3)
+
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		values = append(values, v)
+	})
+
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+
+	if err := BindChannel(context.Background(), ch, &trigger); err != nil {
+		t.Fatalf("Expected nil error for closed channel; got %v", err)
+	}
+	if len(values) != 3 || trigger.Value() != 3 {
+		t.Fatalf("Expected 3 writes ending in 3; got %v", values)
+	}
+}
+
+func TestBindChannelCancel(t *testing.T) {
+	var trigger Trigger
+	ch := make(chan interface{})
+	ctx,cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- BindChannel(ctx, ch, &trigger)
+	}()
+
+	ch <- "value"
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Expected %v; got %v", context.Canceled, err)
+	}
+	if trigger.Value() != "value" {
+		t.Fatalf("Expected value to be set before cancellation; got %v", trigger.Value())
+	}
+}
@@ -0,0 +1,311 @@
+// Package history implements undo and redo for reactor.Initiators. A History
+// records the write events of the Initiators it tracks, and can later reverse
+// or reapply them. Changes are undone and redone through the same methods
+// that made them, such as SetValue, SetAt and Set, so callbacks and bindings
+// respond to an undo exactly as they would to any other write.
+package history
+
+import (
+	"sync"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// WriteTracker is the interface implemented by Initiators whose writes can be
+// tracked with History.Track. reactor.Trigger and reactor.Indicator both
+// implement WriteTracker.
+type WriteTracker interface {
+	reactor.Initiator
+	AddWriteCallback(reactor.WriteCallback)
+}
+
+// change is a single recorded write event, along with how to reverse and
+// reapply it.
+type change struct {
+	undo func()
+	redo func()
+}
+
+// unit is a group of changes that are undone and redone together.
+type unit []change
+
+// History records changes to the Initiators it tracks, so that they can be
+// undone and redone. Changes are recorded in units: outside of a group, each
+// write event is its own unit, and every change made while a group is open
+// belongs to the same unit.
+//
+// Writes caused by Undo and Redo, including those made by bindings, are not
+// recorded, even if they are to tracked Initiators. Changes made by other
+// goroutines while Undo or Redo is running are not recorded either. It is
+// safe to use a History from multiple goroutines.
+type History struct {
+	lock sync.Mutex
+	max int
+	undo []unit
+	redo []unit
+	group unit
+	depth int
+	replaying bool
+}
+
+// New returns a History that keeps at most max units of changes, discarding
+// the oldest once there are more. If max is not positive, every unit is kept.
+func New(max int) *History {
+	return &History{max: max}
+}
+
+// records c, either as part of the open group or as a unit of its own.
+func (h *History) record(c change) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.replaying {
+		return
+	}
+	if h.depth > 0 {
+		h.group = append(h.group, c)
+		return
+	}
+	h.push(unit{c})
+}
+
+// pushes u onto the undo stack, discarding the redo stack and the oldest
+// unit if there are more than max. Must be called with the lock of h held.
+func (h *History) push(u unit) {
+	h.undo = append(h.undo, u)
+	h.redo = nil
+	if h.max > 0 && len(h.undo) > h.max {
+		h.undo = append([]unit(nil), h.undo[len(h.undo)-h.max:]...)
+	}
+}
+
+// Track records every write to i, as reported to its write callbacks. Undoing
+// a change sets i to its previous value, as if by SetValue.
+//
+// Indicators bound to tracked Initiators usually should not be tracked
+// themselves. Undoing a change to the source recomputes them anyway, and
+// their own changes would be recorded as separate units.
+func (h *History) Track(i WriteTracker) {
+	i.AddWriteCallback(func(prev, v interface{}) {
+		h.record(change{
+			undo: func() { i.SetValue(prev) },
+			redo: func() { i.SetValue(v) },
+		})
+	})
+}
+
+// copies a slice value, since slices passed to callbacks may be changed by
+// their owner afterwards.
+func copySlice(v interface{}) []interface{} {
+	s := v.([]interface{})
+	c := make([]interface{}, len(s))
+	copy(c, s)
+	return c
+}
+
+// TrackSlice records every write to s, including those made by SetAt, Append
+// and Pop. Index-level changes are undone through the matching index-level
+// method: SetAt is undone by SetAt, Append by Pop, and Pop by Append. Other
+// writes are undone by SetValue.
+func (h *History) TrackSlice(s *slice.Trigger) {
+	s.AddWriteCallback(func(prev, v interface{}) {
+		p,n := copySlice(prev), copySlice(v)
+		h.record(change{
+			undo: func() { s.SetValue(p) },
+			redo: func() { s.SetValue(n) },
+		})
+	})
+	s.AddIndexWriteCallback(func(prev, v interface{}) {
+		p,n := prev.(slice.Index), v.(slice.Index)
+		switch {
+		case p.Key == -1:
+			h.record(change{
+				undo: func() { s.Pop() },
+				redo: func() { s.Append(n.Value) },
+			})
+		case n.Key == -1:
+			h.record(change{
+				undo: func() { s.Append(p.Value) },
+				redo: func() { s.Pop() },
+			})
+		default:
+			h.record(change{
+				undo: func() { s.SetAt(p.Key, p.Value) },
+				redo: func() { s.SetAt(n.Key, n.Value) },
+			})
+		}
+	})
+}
+
+// copies a map value, for the same reason as copySlice.
+func copyMap(v interface{}) map[interface{}]interface{} {
+	m := v.(map[interface{}]interface{})
+	c := make(map[interface{}]interface{}, len(m))
+	for k,x := range m {
+		c[k] = x
+	}
+	return c
+}
+
+// sets key in t to value, deleting it if value is nil.
+func setOrDelete(t *dict.Trigger, key, value interface{}) {
+	if value == nil {
+		t.Delete(key)
+		return
+	}
+	t.Set(key, value)
+}
+
+// TrackDict records every write to t, including those made by Set and
+// Delete. Key-level changes are undone through Set and Delete, and other
+// writes by SetValue.
+//
+// Since key-level events do not distinguish a missing key from a nil value,
+// a key whose value is nil is treated as missing: restoring it deletes the
+// key, rather than setting it to nil. Deleting a key that did not exist is
+// not recorded.
+func (h *History) TrackDict(t *dict.Trigger) {
+	t.AddWriteCallback(func(prev, v interface{}) {
+		p,n := copyMap(prev), copyMap(v)
+		h.record(change{
+			undo: func() { t.SetValue(p) },
+			redo: func() { t.SetValue(n) },
+		})
+	})
+	t.AddKeyWriteCallback(func(prev, v interface{}) {
+		p,n := prev.(dict.Pair), v.(dict.Pair)
+		if p.Value == nil && n.Value == nil {
+			return
+		}
+		h.record(change{
+			undo: func() { setOrDelete(t, p.Key, p.Value) },
+			redo: func() { setOrDelete(t, n.Key, n.Value) },
+		})
+	})
+}
+
+// Begin opens a group, so that every change recorded until the matching call
+// to End is undone and redone as a single unit. Groups may be nested, in
+// which case the unit ends with the outermost call to End.
+func (h *History) Begin() {
+	h.lock.Lock()
+		h.depth++
+	h.lock.Unlock()
+}
+
+// End closes the group opened by the matching call to Begin. If it is the
+// outermost group and any changes were recorded, they are added as one unit.
+// Calling End without a matching call to Begin does nothing.
+func (h *History) End() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.depth == 0 {
+		return
+	}
+	h.depth--
+	if h.depth == 0 && len(h.group) > 0 {
+		h.push(h.group)
+		h.group = nil
+	}
+}
+
+// Group runs f within a group, as if by Begin and End.
+func (h *History) Group(f func()) {
+	h.Begin()
+	defer h.End()
+	f()
+}
+
+// replays u, using its changes' undo functions in reverse order if undo is
+// true, and its redo functions in order otherwise. The changes are made as if
+// by reactor.Batch, so each Initiator propagates once, and h stops replaying
+// once they have propagated, which is only once any enclosing Batch ends.
+func (h *History) replay(u unit, undo bool) {
+	defer reactor.Defer(func() {
+		h.lock.Lock()
+			h.replaying = false
+		h.lock.Unlock()
+	})
+
+	reactor.Batch(func() {
+		if undo {
+			for n:=len(u)-1; n>=0; n-- {
+				u[n].undo()
+			}
+			return
+		}
+		for _,c := range u {
+			c.redo()
+		}
+	})
+}
+
+// Undo reverses the most recent unit of changes, and reports whether there
+// was one to undo. The unit can then be reapplied with Redo. If a group is
+// open, the changes recorded in it so far are added as a unit first.
+//
+// If Undo or Redo is called during a reactor.Batch, the changes are only
+// propagated once the Batch ends, and until then, no changes are recorded,
+// including other writes made during the same Batch.
+func (h *History) Undo() bool {
+	h.lock.Lock()
+	if len(h.group) > 0 {
+		h.push(h.group)
+		h.group = nil
+	}
+	if len(h.undo) == 0 || h.replaying {
+		h.lock.Unlock()
+		return false
+	}
+	u := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, u)
+	h.replaying = true
+	h.lock.Unlock()
+
+	h.replay(u, true)
+	return true
+}
+
+// Redo reapplies the most recently undone unit of changes, and reports
+// whether there was one to redo. Recording a new change discards every unit
+// that could be redone.
+func (h *History) Redo() bool {
+	h.lock.Lock()
+	if len(h.redo) == 0 || h.replaying {
+		h.lock.Unlock()
+		return false
+	}
+	u := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, u)
+	h.replaying = true
+	h.lock.Unlock()
+
+	h.replay(u, false)
+	return true
+}
+
+// CanUndo reports whether there is a unit of changes to undo.
+func (h *History) CanUndo() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.undo) > 0 || len(h.group) > 0
+}
+
+// CanRedo reports whether there is a unit of changes to redo.
+func (h *History) CanRedo() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.redo) > 0
+}
+
+// Clear discards every recorded change, including those in an open group.
+func (h *History) Clear() {
+	h.lock.Lock()
+		h.undo = nil
+		h.redo = nil
+		h.group = nil
+	h.lock.Unlock()
+}
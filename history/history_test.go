@@ -0,0 +1,191 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+func TestHistoryUndoRedo(t *testing.T) {
+	var trigger reactor.Trigger
+	var doubled reactor.Indicator
+	h := New(0)
+
+	doubled.AddBinding(&trigger, func(v interface{}) interface{} {
+		return v.(int) * 2
+	})
+	trigger.SetValue(1)
+	h.Track(&trigger)
+
+	trigger.SetValue(2)
+	trigger.SetValue(3)
+
+	if !h.Undo() || trigger.Value() != 2 || doubled.Value() != 4 {
+		t.Fatalf("Expected (2, 4) after undo; got (%v, %v)", trigger.Value(), doubled.Value())
+	}
+	if !h.Undo() || trigger.Value() != 1 || doubled.Value() != 2 {
+		t.Fatalf("Expected (1, 2) after undo; got (%v, %v)", trigger.Value(), doubled.Value())
+	}
+	if h.Undo() {
+		t.Fatal("Expected nothing left to undo")
+	}
+	if !h.Redo() || trigger.Value() != 2 || doubled.Value() != 4 {
+		t.Fatalf("Expected (2, 4) after redo; got (%v, %v)", trigger.Value(), doubled.Value())
+	}
+
+	trigger.SetValue(10)
+	if h.CanRedo() {
+		t.Fatal("Expected a new change to discard redo")
+	}
+}
+
+func TestHistoryGroup(t *testing.T) {
+	var a,b reactor.Trigger
+	h := New(0)
+	a.SetValue(0)
+	b.SetValue(0)
+	h.Track(&a)
+	h.Track(&b)
+
+	h.Group(func() {
+		a.SetValue(1)
+		h.Group(func() {
+			b.SetValue(1)
+		})
+		a.SetValue(2)
+	})
+
+	h.Undo()
+	if a.Value() != 0 || b.Value() != 0 {
+		t.Fatalf("Expected group to be undone together; got (%v, %v)", a.Value(), b.Value())
+	}
+	if h.CanUndo() {
+		t.Fatal("Expected group to be a single unit")
+	}
+	h.Redo()
+	if a.Value() != 2 || b.Value() != 1 {
+		t.Fatalf("Expected (2, 1) after redo; got (%v, %v)", a.Value(), b.Value())
+	}
+}
+
+func TestHistoryMaxDepth(t *testing.T) {
+	var trigger reactor.Trigger
+	h := New(2)
+	trigger.SetValue(0)
+	h.Track(&trigger)
+
+	for n:=1; n<=5; n++ {
+		trigger.SetValue(n)
+	}
+
+	undone := 0
+	for h.Undo() {
+		undone += 1
+	}
+	if undone != 2 || trigger.Value() != 3 {
+		t.Fatalf("Expected 2 undos back to 3; got %d undos back to %v", undone, trigger.Value())
+	}
+}
+
+func TestHistorySlice(t *testing.T) {
+	var s slice.Trigger
+	h := New(0)
+	h.TrackSlice(&s)
+
+	s.SetValue([]interface{}{"a"})
+	s.Append("b")
+	s.SetAt(0, "z")
+	s.Pop()
+
+	want := [][]interface{}{{"z", "b"}, {"a", "b"}, {"a"}, {}}
+	for _,w := range want {
+		h.Undo()
+		if !reflect.DeepEqual(s.Value(), w) {
+			t.Fatalf("Expected %v; got %v", w, s.Value())
+		}
+	}
+
+	for h.Redo() {
+	}
+	if !reflect.DeepEqual(s.Value(), []interface{}{"z"}) {
+		t.Fatalf("Expected [z] after redoing everything; got %v", s.Value())
+	}
+}
+
+func TestHistoryDict(t *testing.T) {
+	var d dict.Trigger
+	var bound []interface{}
+	h := New(0)
+	d.AddKeyBinder("a", nil, func(v interface{}) interface{} {
+		bound = append(bound, v.(dict.Pair).Value)
+		return nil
+	}, true)
+	h.TrackDict(&d)
+
+	d.Set("a", 1)
+	d.Set("a", 2)
+	d.Delete("a")
+	d.Delete("missing")
+
+	h.Undo()
+	if v,ok := d.GetCheck("a"); !ok || v != 2 {
+		t.Fatalf("Expected delete to be undone; got %v", d.Value())
+	}
+	h.Undo()
+	h.Undo()
+	if _,ok := d.GetCheck("a"); ok {
+		t.Fatalf("Expected a to be removed by undoing its creation; got %v", d.Value())
+	}
+	if !reflect.DeepEqual(bound, []interface{}{1, 2, nil, 2, 1, nil}) {
+		t.Fatalf("Expected key bindings to run for every undo; got %v", bound)
+	}
+}
+
+func TestHistoryUndoInBatch(t *testing.T) {
+	var trigger reactor.Trigger
+	h := New(0)
+	trigger.SetValue(1)
+	h.Track(&trigger)
+	trigger.SetValue(2)
+
+	reactor.Batch(func() {
+		h.Undo()
+	})
+	if trigger.Value() != 1 || !h.CanRedo() {
+		t.Fatalf("Expected undo to 1 without recording it; got %v with redo %v", trigger.Value(), h.CanRedo())
+	}
+	trigger.SetValue(3)
+	if !h.CanUndo() || h.CanRedo() {
+		t.Fatal("Expected changes after the Batch to be recorded")
+	}
+}
+
+func TestHistoryUndoDuringOtherBatch(t *testing.T) {
+	var trigger reactor.Trigger
+	h := New(0)
+	trigger.SetValue(1)
+	h.Track(&trigger)
+	trigger.SetValue(2)
+
+	started := make(chan bool)
+	release := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		reactor.Batch(func() {
+			started <- true
+			<-release
+		})
+		done <- true
+	}()
+	<-started
+	h.Undo()
+	close(release)
+	<-done
+
+	if trigger.Value() != 1 || !h.CanRedo() {
+		t.Fatalf("Expected undo to 1 without recording it; got %v with redo %v", trigger.Value(), h.CanRedo())
+	}
+}
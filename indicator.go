@@ -1,6 +1,7 @@
 package reactor
 
 import (
+	"context"
 	"sync"
 )
 
@@ -9,10 +10,18 @@ import (
 // concurrent callbacks.
 type Indicator struct {
 	Lock sync.Mutex
+	Probe
 	value interface{}
+	version uint64
+	samples samples
+
+	err error
+	propagateErrors bool
 
 	readCallbacks []ReadCallback
 	writeCallbacks []WriteCallback
+	errorCallbacks []ErrorCallback
+	watchers Watchers
 
 	bindings []Binding // dependent binders
 	delayedBindings []Binding
@@ -27,51 +36,81 @@ type Indicator struct {
 // The value(s) passed to the callback are as follows, in order: the current 
 // value.
 func (n *Indicator) Value() interface{} {
+	v,_ := n.ValueVersion()
+	return v
+}
+
+// ValueVersion returns the value of n, as if by Value, along with the version 
+// of n when it was read. If n has delayed bindings, this is the version set by 
+// evaluating them.
+func (n *Indicator) ValueVersion() (interface{}, uint64) {
+	start := StartEvent(&n.Probe)
 	n.Lock.Lock()
 		v := n.value
+		version := n.version
 	n.Lock.Unlock()
 	for _,b := range n.delayedBindings {
-		v = b.F(b.Source.Value())
+		v = RunDelayed(&n.Probe, b)
 	}
 
 	if len(n.delayedBindings) > 0 {
-		n.SetValue(v)
+		Settle(n, v)
+		n.Lock.Lock()
+			v = n.value
+			version = n.version
+		n.Lock.Unlock()
 	}
 
 	for _,c := range n.readCallbacks {
 		c(v)
 	}
+	EmitEvent(&n.Probe, Event{Kind: ReadEvent, Value: v}, start)
 
-	return v
+	return v, version
 }
 
 // SetValue sets the value underlying n and runs any callbacks associated 
 // with writing. If the current value is nil (for example, if n has not been 
-// set yet), the previous value in callbacks will be nil.
+// set yet), the previous value in callbacks will be nil. Any error recorded 
+// by a failed binding is cleared.
 //
 // The value(s) passed to the callback are as follows, in order: the previous 
-// value and the current value.
+// value and the current value. If SetValue is called during a Batch, callbacks 
+// and bindings are held back until the Batch ends.
 func (n *Indicator) SetValue(v interface{}) {
 	n.Lock.Lock()
 		prev := n.value
 		n.value = v
+		n.version++
+		n.samples.add(n.value)
+		n.err = nil
 	n.Lock.Unlock()
 
+	if Batched(n, prev, v, n.propagate) {
+		return
+	}
+	n.propagate(prev, v)
+}
+
+// runs the write callbacks and bindings of n for a change from prev to v.
+func (n *Indicator) propagate(prev, v interface{}) {
+	start := StartEvent(&n.Probe)
 	for _,c := range n.writeCallbacks {
 		c(prev, v)
 	}
 
+	n.watchers.Notify(prev, v)
+
 	for _,b := range n.bindings {
-		val := b.F(v)
-		if !b.Concurrent {
-			b.Binder.SetValue(val)
-		}
+		RunBinding(&n.Probe, b, v)
 	}
+	EmitEvent(&n.Probe, Event{Kind: WriteEvent, Prev: prev, Value: v}, start)
 }
 
 // AddBinder adds a Binder to be executed when the value of n changes. If 
 // concurrent is true, b.SetValue will not be called when n changes; instead it
-// will be queued for change.
+// will be queued for change. Otherwise, f may return Unchanged to leave b 
+// unchanged.
 //
 // AddBinder is largely intended for use in implementing Binders, and its 
 // use is heavily discouraged outside of that. If concurrent is true outside 
@@ -79,18 +118,138 @@ func (n *Indicator) SetValue(v interface{}) {
 // If concurrent is false, this will have exactly the same effect as 
 // Binder.AddBinding(), which is the preferred method of creating bindings.
 func (n *Indicator) AddBinder(b Binder, f BindingFunc, concurrent bool) {
-	n.bindings = append(n.bindings, Binding{n, b, f, concurrent})
+	n.bindings = append(n.bindings, Binding{n, b, WatchBinding(&n.Probe, BindingEvent, b, f), concurrent})
+}
+
+// Version returns the version of n, which is increased every time n is 
+// written to, whether directly or by a binding.
+func (n *Indicator) Version() uint64 {
+	n.Lock.Lock()
+		version := n.version
+	n.Lock.Unlock()
+	return version
+}
+
+// CompareAndSet sets the value of n to v, as if by SetValue, but only if the 
+// version of n is still version. CompareAndSet reports whether the value was 
+// set. No callbacks are run if it was not.
+func (n *Indicator) CompareAndSet(version uint64, v interface{}) bool {
+	n.Lock.Lock()
+		ok := n.version == version
+		prev := n.value
+		if ok {
+			n.value = v
+			n.version++
+			n.samples.add(n.value)
+			n.err = nil
+		}
+	n.Lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	if !Batched(n, prev, v, n.propagate) {
+		n.propagate(prev, v)
+	}
+	return true
+}
+
+// Update sets the value of n to the result of calling f with the current 
+// value of n, and returns the new value. f is called while holding the lock 
+// on n, so no other write can happen between reading and writing, but f must 
+// not access n itself. Delayed bindings are not evaluated, and no read 
+// callbacks are run. Callbacks and bindings are run as if by SetValue, after 
+// the lock is released. If f returns Unchanged, n is not written, as described 
+// for Trigger.Update.
+func (n *Indicator) Update(f func(interface{}) interface{}) interface{} {
+	n.Lock.Lock()
+		prev := n.value
+		v := f(prev)
+		if v == Unchanged {
+			n.Lock.Unlock()
+			return prev
+		}
+		n.value = v
+		n.version++
+		n.samples.add(n.value)
+		n.err = nil
+	n.Lock.Unlock()
+
+	if !Batched(n, prev, v, n.propagate) {
+		n.propagate(prev, v)
+	}
+	return v
+}
+
+// Err returns the error returned by the most recent failed fallible binding 
+// of n. If n has been set since that failure, either directly or by another 
+// binding, Err returns nil.
+func (n *Indicator) Err() error {
+	n.Lock.Lock()
+		err := n.err
+	n.Lock.Unlock()
+	return err
+}
+
+// Fail records err as the error of n without changing its value, and runs 
+// any callbacks registered with AddErrorCallback. If error propagation is 
+// enabled for n, err is also passed to Fail for any dependent Binders that 
+// implement FallibleBinder.
+//
+// Fail is largely intended for propagating errors between Binders, and its 
+// use is discouraged outside of that. Failed fallible bindings call Fail 
+// automatically.
+func (n *Indicator) Fail(err error) {
+	n.Lock.Lock()
+		n.err = err
+		propagate := n.propagateErrors
+	n.Lock.Unlock()
+
+	for _,c := range n.errorCallbacks {
+		c(err)
+	}
+
+	if !propagate {
+		return
+	}
+	for _,b := range n.bindings {
+		if f,ok := b.Binder.(FallibleBinder); ok {
+			f.Fail(err)
+		}
+	}
+}
+
+// PropagateErrors sets whether errors recorded by n are passed on to the 
+// Binders that depend on n. Errors are not propagated by default.
+func (n *Indicator) PropagateErrors(propagate bool) {
+	n.Lock.Lock()
+		n.propagateErrors = propagate
+	n.Lock.Unlock()
+}
+
+// AddErrorCallback adds a callback that will be run when a fallible binding 
+// of n fails, or an error is propagated to n.
+func (n *Indicator) AddErrorCallback(e ErrorCallback) {
+	n.errorCallbacks = append(n.errorCallbacks, e)
+}
+
+// Watch returns a channel that receives a Change for every write to n, 
+// whenever its write callbacks are run, until ctx is cancelled. The channel 
+// is closed once ctx is cancelled. mode determines how changes are handled 
+// when they are made faster than they are received.
+func (n *Indicator) Watch(ctx context.Context, mode WatchMode) <-chan Change {
+	return n.watchers.Add(ctx, mode)
 }
 
 // AddReadCallback adds a callback that will be run when n is read using Value.
 func (n *Indicator) AddReadCallback(r ReadCallback) {
-    n.readCallbacks = append(n.readCallbacks, r)
+    n.readCallbacks = append(n.readCallbacks, WatchRead(&n.Probe, ReadEvent, r))
 }
 
 // AddWriteCallback adds a callback that will be run when n is written to 
 // using SetValue.
 func (n *Indicator) AddWriteCallback(w WriteCallback) {
-    n.writeCallbacks = append(n.writeCallbacks, w)
+    n.writeCallbacks = append(n.writeCallbacks, WatchWrite(&n.Probe, WriteEvent, w))
 }
 
 // AddBinding binds n to i, with the value of n being determined by calling f 
@@ -108,7 +267,7 @@ var TrivialBinding = BindingFunc(func(v interface{}) interface{} {return v})
 // moment. Consequently, this binding behaves differently from the others, 
 // and any side effects will be affected as such.
 func (n *Indicator) AddDelayedBinding(i Initiator, f BindingFunc) {
-	n.delayedBindings = append(n.delayedBindings, Binding{i, n, f, false})
+	n.delayedBindings = append(n.delayedBindings, Binding{i, n, WatchBinding(&n.Probe, DelayedBindingEvent, i, f), false})
 }
 
 // AddConcurrentBinding bind n to i, with the value of n being eventually 
@@ -116,17 +275,12 @@ func (n *Indicator) AddDelayedBinding(i Initiator, f BindingFunc) {
 // the value of i immediately after it triggers the binding, to ensure 
 // consistency.
 func (n *Indicator) AddConcurrentBinding(i Initiator, f BindingFunc) {
-	conBindLock.Lock()
-		if conBind == nil {
-			conBind = make(chan conBindState, 100)
-			go runConcurrentBind()
-		}
-	conBindLock.Unlock()
-
-	c := func(v interface{}) interface{} {
-		conBind <- conBindState{v, n, f}
-		return nil
-	}
-	i.AddBinder(n, c, true)
+	i.AddBinder(n, QueueBinding(n, f), true)
 }
 
+// AddFallibleBinding binds n to i, with the value of n being determined by 
+// calling f with the value of i. If f returns a non-nil error, n keeps its 
+// previous value and the error is recorded as if by Fail.
+func (n *Indicator) AddFallibleBinding(i Initiator, f FallibleBindingFunc) {
+	i.AddBinder(n, FallibleBinding(f), false)
+}
@@ -1,6 +1,7 @@
 package reactor
 
 import (
+	"errors"
 	"testing"
 	"strconv"
 )
@@ -618,3 +619,154 @@ func TestMultipleBindings(t *testing.T) {
 				 trigger.Value().(int) * 2, trigger.Value(), ind2.Value())
 	}
 }
+
+func TestFallibleBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	var errs []error
+	bindFunc := func(v interface{}) (interface{}, error) {
+		return strconv.Atoi(v.(string))
+	}
+
+	ind.AddFallibleBinding(&trigger, bindFunc)
+	ind.AddErrorCallback(func(err error) {
+		errs = append(errs, err)
+	})
+
+	trigger.SetValue("10")
+	if ind.Value() != 10 || ind.Err() != nil {
+		t.Fatalf("Expected value 10 and nil error; got %v and %v", ind.Value(), ind.Err())
+	}
+
+	trigger.SetValue("ten")
+	if ind.Value() != 10 {
+		t.Fatalf("Failed binding should keep previous value 10; got %v", ind.Value())
+	}
+	if ind.Err() == nil || len(errs) != 1 {
+		t.Fatalf("Expected one error; got %v with %d callbacks", ind.Err(), len(errs))
+	}
+
+	trigger.SetValue("11")
+	if ind.Value() != 11 || ind.Err() != nil {
+		t.Fatalf("Expected value 11 and cleared error; got %v and %v", ind.Value(), ind.Err())
+	}
+}
+
+func TestFallibleBindingPropagation(t *testing.T) {
+	var trigger Trigger
+	var ind1,ind2,ind3 Indicator
+	bindFunc := func(v interface{}) (interface{}, error) {
+		return strconv.Atoi(v.(string))
+	}
+
+	ind1.AddFallibleBinding(&trigger, bindFunc)
+	ind2.AddBinding(&ind1, TrivialBinding)
+	ind3.AddBinding(&ind2, TrivialBinding)
+	ind1.PropagateErrors(true)
+
+	trigger.SetValue("1")
+	trigger.SetValue("one")
+
+	if ind2.Err() == nil {
+		t.Fatal("Expected error to propagate to dependent Indicator")
+	}
+	if ind2.Value() != 1 {
+		t.Fatalf("Propagated error should keep previous value 1; got %v", ind2.Value())
+	}
+	if ind3.Err() != nil {
+		t.Fatalf("Error should not propagate past Indicator without propagation; got %v", ind3.Err())
+	}
+
+	ind1.PropagateErrors(false)
+	ind2.SetValue(2)
+	trigger.SetValue("two")
+	if ind2.Err() != nil {
+		t.Fatalf("Error should not propagate once disabled; got %v", ind2.Err())
+	}
+}
+
+func TestFallibleBindingSetsThroughInitiator(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	ind.AddFallibleBinding(&trigger, func(v interface{}) (interface{}, error) {
+		return v, nil
+	})
+
+	if trigger.bindings[0].Concurrent {
+		t.Fatal("Fallible binding should not be registered as concurrent")
+	}
+	trigger.SetValue(1)
+	if ind.Value() != 1 {
+		t.Fatalf("Expected value 1; got %v", ind.Value())
+	}
+}
+
+func TestBindingUnchanged(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	writes := 0
+	ind.AddWriteCallback(func(prev, v interface{}) {
+		writes++
+	})
+	trigger.AddBinder(&ind, func(v interface{}) interface{} {
+		if v.(int) < 0 {
+			return Unchanged
+		}
+		return v
+	}, false)
+
+	trigger.SetValue(1)
+	trigger.SetValue(-1)
+	if ind.Value() != 1 || writes != 1 {
+		t.Fatalf("Expected value 1 after 1 write; got %v after %d", ind.Value(), writes)
+	}
+}
+
+func TestDelayedBindingUnchanged(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	bad := errors.New("negative")
+	ind.AddDelayedBinding(&trigger, FallibleBinding(func(v interface{}) (interface{}, error) {
+		switch {
+		case v.(int) < 0:
+			return nil, bad
+		case v.(int) == 0:
+			return Unchanged, nil
+		}
+		return v, nil
+	}))
+
+	trigger.SetValue(1)
+	if ind.Value() != 1 {
+		t.Fatalf("Expected 1; got %v", ind.Value())
+	}
+	trigger.SetValue(0)
+	if ind.Value() != 1 {
+		t.Fatalf("Expected Unchanged to keep 1; got %v", ind.Value())
+	}
+	trigger.SetValue(-1)
+	if ind.Value() != 1 || ind.Err() != bad {
+		t.Fatalf("Expected failure to keep 1 and record its error; got %v, %v", ind.Value(), ind.Err())
+	}
+}
+
+func TestIndicatorCompareAndSet(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+
+	ind.AddBinding(&trigger, TrivialBinding)
+	trigger.SetValue(1)
+
+	version := ind.Version()
+	trigger.SetValue(2)
+	if ind.CompareAndSet(version, 10) {
+		t.Fatal("CompareAndSet succeeded after binding changed the Indicator")
+	}
+
+	got := ind.Update(func(v interface{}) interface{} {
+		return v.(int) * 10
+	})
+	if got != 20 || ind.Value() != 20 {
+		t.Fatalf("Expected Update to produce 20; got %v and %v", got, ind.Value())
+	}
+}
@@ -0,0 +1,74 @@
+// Package journal records the read, write and binding events of a set of
+// reactor.Initiators to a file, and replays them into a freshly built graph.
+// Since every event is numbered in the order it happened, replaying a journal
+// reproduces the exact sequence of reads and writes that led to a problem,
+// including the order in which callbacks and bindings ran.
+//
+// A journal is a file of JSON lines, one per event. Values must therefore be
+// encodable with encoding/json. Maps used by dict Triggers are encoded with
+// string keys, as described for dict.EncodeKey.
+package journal
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Kind identifies the type of an Event.
+type Kind string
+
+// The kinds of Event recorded in a journal. Index events come from slice
+// Triggers, and key events from dict Triggers.
+const (
+	Read Kind = "r"
+	Write Kind = "w"
+	IndexRead Kind = "ir"
+	IndexWrite Kind = "iw"
+	KeyRead Kind = "kr"
+	KeyWrite Kind = "kw"
+	Binding Kind = "b"
+	DelayedBinding Kind = "db"
+	ConcurrentBinding Kind = "cb"
+)
+
+// Event is a single event in a journal. Seq increases by one with every
+// event recorded by a Recorder, in the order the events happened, and Name is
+// the name the Initiator was recorded with.
+//
+// Value is the encoded value passed to the read or write callback that
+// recorded the event. For write events, Prev is the encoded previous value;
+// for read events, it is nil. For index and key events, both are encoded
+// slice.Index or dict.Pair structs, respectively.
+//
+// Binding events are recorded from the reactor.Events traced by the
+// Initiator, as described for reactor.Event, once the binding has finished,
+// and so after any events it caused. Prev is the value passed to the
+// BindingFunc, Value is its result, and Target is the name of the other
+// Initiator involved, if it has been named.
+type Event struct {
+	Seq uint64
+	Time time.Time
+	Name string
+	Kind Kind
+	Target string
+	Prev json.RawMessage
+	Value json.RawMessage
+}
+
+// wireEvent is the encoding of an Event in a journal, using short field
+// names to keep journals compact.
+type wireEvent struct {
+	Seq uint64 `json:"s"`
+	Time int64 `json:"t"`
+	Name string `json:"n"`
+	Kind Kind `json:"k"`
+	Target string `json:"g,omitempty"`
+	Prev json.RawMessage `json:"p,omitempty"`
+	Value json.RawMessage `json:"v"`
+}
+
+// element is the encoding of slice.Index and dict.Pair values.
+type element struct {
+	Key json.RawMessage
+	Value json.RawMessage
+}
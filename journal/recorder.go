@@ -0,0 +1,231 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+)
+
+// ErrNotRecordable is the error returned by Recorder.Record when an Initiator
+// does not support any callbacks that could be recorded.
+var ErrNotRecordable = errors.New("journal: Initiator does not support read or write callbacks")
+
+// ErrClosed is the error recorded when a Recorder is written to after it has
+// been closed.
+var ErrClosed = errors.New("journal: recorder is closed")
+
+// Interfaces for each set of callbacks that can be recorded.
+type readRecordable interface {
+	AddReadCallback(reactor.ReadCallback)
+}
+
+type writeRecordable interface {
+	AddWriteCallback(reactor.WriteCallback)
+}
+
+type indexRecordable interface {
+	AddIndexReadCallback(reactor.ReadCallback)
+	AddIndexWriteCallback(reactor.WriteCallback)
+}
+
+type keyRecordable interface {
+	AddKeyReadCallback(reactor.ReadCallback)
+	AddKeyWriteCallback(reactor.WriteCallback)
+}
+
+type traceRecordable interface {
+	AddTracer(reactor.Tracer)
+}
+
+// the Kind recorded for each kind of traced binding event.
+var bindingKinds = map[reactor.EventKind]Kind{
+	reactor.BindingEvent: Binding,
+	reactor.DelayedBindingEvent: DelayedBinding,
+	reactor.ConcurrentBindingEvent: ConcurrentBinding,
+}
+
+// Recorder writes the events of the Initiators it records to a journal.
+// Events are buffered, and are only guaranteed to be written once Flush or
+// Close is called. It is safe to use a Recorder from multiple goroutines.
+type Recorder struct {
+	lock sync.Mutex
+	w *bufio.Writer
+	seq uint64
+	closed bool
+	err error
+}
+
+// NewRecorder returns a Recorder that writes a journal to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: bufio.NewWriter(w)}
+}
+
+// encodes v, converting maps used by dict Triggers to use string keys.
+func encode(v interface{}) (json.RawMessage, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m,err := dict.EncodeMap(v)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(m)
+	case dict.Pair:
+		key,err := dict.EncodeKey(v.Key)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(dict.Pair{Key: key, Value: v.Value})
+	}
+	return json.Marshal(v)
+}
+
+// records err as the error of r, if there is not one already. Must be called
+// with the lock of r held.
+func (r *Recorder) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// writes an event to the journal. prev is only encoded for write and binding
+// events. The event is numbered and encoded while holding the lock of r, so
+// events are numbered and written in the order they are recorded.
+func (r *Recorder) event(name string, kind Kind, target string, prev, v interface{}, write bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		r.fail(ErrClosed)
+		return
+	}
+
+	e := wireEvent{Seq: r.seq+1, Time: time.Now().UnixNano(), Name: name, Kind: kind, Target: target}
+	var err error
+	if write {
+		e.Prev,err = encode(prev)
+	}
+	if err == nil {
+		e.Value,err = encode(v)
+	}
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	r.seq = e.Seq
+
+	data,err := json.Marshal(e)
+	if err == nil {
+		_,err = r.w.Write(append(data, '\n'))
+	}
+	if err != nil {
+		r.fail(err)
+	}
+}
+
+// Record records every read and write event of i under name, including index
+// events for slice Triggers and key events for dict Triggers. The binding
+// events of i are recorded as well, by adding a Tracer to i with AddTracer,
+// which leaves any other Tracer of i in place. If i supports none of the
+// callbacks needed to record events, ErrNotRecordable is returned.
+//
+// Callbacks and Tracers cannot be removed, so i continues to run those added
+// by Record after r is closed, although they no longer record anything.
+func (r *Recorder) Record(name string, i reactor.Initiator) error {
+	ok := false
+	if x,is := i.(readRecordable); is {
+		x.AddReadCallback(func(v interface{}) {
+			r.event(name, Read, "", nil, v, false)
+		})
+		ok = true
+	}
+	if x,is := i.(writeRecordable); is {
+		x.AddWriteCallback(func(prev, v interface{}) {
+			r.event(name, Write, "", prev, v, true)
+		})
+		ok = true
+	}
+	if x,is := i.(indexRecordable); is {
+		x.AddIndexReadCallback(func(v interface{}) {
+			r.event(name, IndexRead, "", nil, v, false)
+		})
+		x.AddIndexWriteCallback(func(prev, v interface{}) {
+			r.event(name, IndexWrite, "", prev, v, true)
+		})
+		ok = true
+	}
+	if x,is := i.(keyRecordable); is {
+		x.AddKeyReadCallback(func(v interface{}) {
+			r.event(name, KeyRead, "", nil, v, false)
+		})
+		x.AddKeyWriteCallback(func(prev, v interface{}) {
+			r.event(name, KeyWrite, "", prev, v, true)
+		})
+		ok = true
+	}
+	if x,is := i.(traceRecordable); is && ok {
+		x.AddTracer(reactor.TracerFunc(func(e reactor.Event) {
+			if kind,is := bindingKinds[e.Kind]; is {
+				r.event(name, kind, e.Target, e.Prev, e.Value, true)
+			}
+		}))
+	}
+
+	if !ok {
+		return ErrNotRecordable
+	}
+	return nil
+}
+
+// RecordRegistry records every Initiator in reg under the name it is
+// registered with, as if by Record. Initiators registered later are not
+// recorded. The first error returned by Record is returned, after every
+// Initiator that can be recorded has been.
+func (r *Recorder) RecordRegistry(reg *reactor.Registry) error {
+	var first error
+	for _,name := range reg.Names() {
+		i,ok := reg.Lookup(name)
+		if !ok {
+			continue
+		}
+		if err := r.Record(name, i); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Flush writes any buffered events to the underlying io.Writer.
+func (r *Recorder) Flush() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.w.Flush(); err != nil {
+		r.fail(err)
+		return err
+	}
+	return nil
+}
+
+// Close flushes r and stops it from recording any more events. Close does
+// not close the underlying io.Writer.
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.w.Flush()
+}
+
+// Err returns the first error encountered encoding or writing an event, or
+// nil if there has not been one.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
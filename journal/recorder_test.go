@@ -0,0 +1,122 @@
+package journal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// reads every Event in data.
+func readAll(t *testing.T, data []byte) []Event {
+	var events []Event
+	r := NewReader(bytes.NewReader(data))
+	for {
+		e,err := r.Next()
+		if err == io.EOF {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		events = append(events, e)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	var trigger reactor.Trigger
+	var s slice.Trigger
+	var d dict.Trigger
+	r := NewRecorder(&buf)
+
+	for name,i := range map[string]reactor.Initiator{"t": &trigger, "s": &s, "d": &d} {
+		if err := r.Record(name, i); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	trigger.SetValue(1)
+	trigger.Value()
+	s.Append("a")
+	s.At(0)
+	d.Set(2, "two")
+	d.Get(2)
+	r.Close()
+
+	if err := r.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	events := readAll(t, buf.Bytes())
+	kinds := []Kind{Write, Read, IndexWrite, IndexRead, KeyWrite, KeyRead}
+	if len(events) != len(kinds) {
+		t.Fatalf("Expected %d events; got %d", len(kinds), len(events))
+	}
+	for n,e := range events {
+		if e.Seq != uint64(n+1) || e.Kind != kinds[n] {
+			t.Fatalf("Expected event %d to be %s; got %d %s", n+1, kinds[n], e.Seq, e.Kind)
+		}
+	}
+	if string(events[4].Value) != `{"Key":"2","Value":"two"}` {
+		t.Fatalf("Expected key to be encoded as a string; got %s", events[4].Value)
+	}
+
+	trigger.SetValue(2)
+	if r.Err() != ErrClosed {
+		t.Fatalf("Expected ErrClosed after Close; got %v", r.Err())
+	}
+}
+
+func TestRecorderBindings(t *testing.T) {
+	var buf bytes.Buffer
+	var source reactor.Trigger
+	var doubled, delayed reactor.Indicator
+	source.SetName("source")
+	doubled.SetName("doubled")
+	double := func(v interface{}) interface{} {
+		return v.(int) * 2
+	}
+	doubled.AddBinding(&source, double)
+	delayed.AddDelayedBinding(&source, double)
+
+	traced := 0
+	source.SetTracer(reactor.TracerFunc(func(e reactor.Event) {
+		traced++
+	}))
+	r := NewRecorder(&buf)
+	r.Record("source", &source)
+	r.Record("delayed", &delayed)
+
+	source.SetValue(1)
+	delayed.Value()
+	r.Close()
+
+	events := readAll(t, buf.Bytes())
+	kinds := []Kind{Write, Binding, Read, DelayedBinding, Write, Read}
+	if len(events) != len(kinds) {
+		t.Fatalf("Expected %d events; got %d", len(kinds), len(events))
+	}
+	for n,e := range events {
+		if e.Seq != uint64(n+1) || e.Kind != kinds[n] {
+			t.Fatalf("Expected event %d to be %s; got %d %s", n+1, kinds[n], e.Seq, e.Kind)
+		}
+	}
+	if e := events[1]; e.Name != "source" || e.Target != "doubled" || string(e.Prev) != "1" || string(e.Value) != "2" {
+		t.Fatalf("Unexpected binding event %+v", e)
+	}
+	if traced == 0 {
+		t.Fatal("Expected the Tracer of source to be kept")
+	}
+}
+
+func TestRecorderNotRecordable(t *testing.T) {
+	r := NewRecorder(&bytes.Buffer{})
+	var e slice.Element
+	if err := r.Record("element", &e); err != ErrNotRecordable {
+		t.Fatalf("Expected ErrNotRecordable; got %v", err)
+	}
+}
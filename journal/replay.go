@@ -0,0 +1,263 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+)
+
+// Reader reads the Events of a journal, in order.
+type Reader struct {
+	r *bufio.Reader
+	line int
+}
+
+// NewReader returns a Reader that reads a journal from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next Event in the journal. At the end of the journal, Next
+// returns io.EOF. A partial event at the end of the journal, as left by a
+// crash in the middle of a write, is treated as the end of the journal.
+func (r *Reader) Next() (Event, error) {
+	for {
+		data,err := r.r.ReadBytes('\n')
+		if err == io.EOF {
+			return Event{}, io.EOF
+		}
+		if err != nil {
+			return Event{}, err
+		}
+		r.line++
+		if len(data) <= 1 {
+			continue
+		}
+
+		var e wireEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return Event{}, fmt.Errorf("journal: line %d: %v", r.line, err)
+		}
+		return Event{
+			Seq: e.Seq,
+			Time: time.Unix(0, e.Time),
+			Name: e.Name,
+			Kind: e.Kind,
+			Target: e.Target,
+			Prev: e.Prev,
+			Value: e.Value,
+		}, nil
+	}
+}
+
+// Options configures how Replay decodes values.
+type Options struct {
+	// Decode converts a value from the journal back into the value that was
+	// recorded. For index and key events, it is called with the element
+	// value. If Decode is nil, values are decoded as they would be into an
+	// interface{}.
+	Decode func(json.RawMessage) (interface{}, error)
+
+	// DecodeKey converts the keys of dict Triggers, which are stored as
+	// strings. If DecodeKey is nil, keys are left as strings.
+	DecodeKey dict.KeyDecoder
+}
+
+func (o *Options) decode(raw json.RawMessage) (interface{}, error) {
+	if o.Decode != nil {
+		return o.Decode(raw)
+	}
+	var v interface{}
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+func (o *Options) decodeKey(k string) (interface{}, error) {
+	if o.DecodeKey != nil {
+		return o.DecodeKey(k)
+	}
+	return k, nil
+}
+
+// Interfaces for the methods used to replay index and key events.
+type indexed interface {
+	At(int) (interface{}, error)
+	SetAt(int, interface{}) error
+	Append(interface{})
+	Pop() (interface{}, error)
+}
+
+type keyed interface {
+	Get(interface{}) interface{}
+	Set(interface{}, interface{})
+	Delete(interface{})
+}
+
+// Replay reads every Event of the journal in r, in order, and replays it
+// against the Initiator registered under its name in reg. Events for names
+// not registered in reg are skipped.
+//
+// Read events are replayed with the matching read method, such as Value, At
+// or Get, so read callbacks and delayed bindings run in the same order they
+// did originally. Write events are replayed with SetValue, and index and key
+// events with SetAt, Append, Pop, Set and Delete, as appropriate. As for
+// history.History, a key written with a nil value is deleted. Binding events
+// are not replayed, since the bindings of the graph run again by themselves.
+//
+// Writes made by bindings are recorded as well, so replaying into a graph
+// whose bindings are in place will repeat them. To reproduce the behaviour of
+// a graph, register only its sources in reg, and let the bindings recompute
+// the rest. If opts is nil, the zero value is used.
+func Replay(r io.Reader, reg *reactor.Registry, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	jr := NewReader(r)
+	for {
+		e,err := jr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		i,ok := reg.Lookup(e.Name)
+		if !ok {
+			continue
+		}
+		if err := apply(e, i, opts); err != nil {
+			return fmt.Errorf("journal: event %d (%s): %v", e.Seq, e.Name, err)
+		}
+	}
+}
+
+// replays e against i.
+func apply(e Event, i reactor.Initiator, opts *Options) error {
+	switch e.Kind {
+	case Read:
+		i.Value()
+		return nil
+	case Write:
+		v,err := opts.decode(e.Value)
+		if err != nil {
+			return err
+		}
+		if _,ok := i.(keyed); ok {
+			if v,err = decodeMap(v, opts); err != nil {
+				return err
+			}
+		}
+		i.SetValue(v)
+		return nil
+	case IndexRead, IndexWrite:
+		s,ok := i.(indexed)
+		if !ok {
+			return fmt.Errorf("%s events are not supported", e.Kind)
+		}
+		return applyIndex(e, s, opts)
+	case KeyRead, KeyWrite:
+		t,ok := i.(keyed)
+		if !ok {
+			return fmt.Errorf("%s events are not supported", e.Kind)
+		}
+		return applyKey(e, t, opts)
+	case Binding, DelayedBinding, ConcurrentBinding:
+		return nil
+	}
+	return fmt.Errorf("unknown event kind %q", e.Kind)
+}
+
+// converts a decoded JSON object to a map with keys decoded by opts.
+func decodeMap(v interface{}, opts *Options) (interface{}, error) {
+	m,ok := v.(map[string]interface{})
+	if !ok {
+		return v, nil
+	}
+	n := make(map[interface{}]interface{}, len(m))
+	for k,x := range m {
+		key,err := opts.decodeKey(k)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = x
+	}
+	return n, nil
+}
+
+// decodes an encoded slice.Index, returning its index and value.
+func decodeIndex(raw json.RawMessage, opts *Options) (int, interface{}, error) {
+	var el element
+	if err := json.Unmarshal(raw, &el); err != nil {
+		return 0, nil, err
+	}
+	var index int
+	if err := json.Unmarshal(el.Key, &index); err != nil {
+		return 0, nil, err
+	}
+	v,err := opts.decode(el.Value)
+	return index, v, err
+}
+
+func applyIndex(e Event, s indexed, opts *Options) error {
+	index,v,err := decodeIndex(e.Value, opts)
+	if err != nil {
+		return err
+	}
+	if e.Kind == IndexRead {
+		s.At(index)
+		return nil
+	}
+
+	prev,_,err := decodeIndex(e.Prev, opts)
+	if err != nil {
+		return err
+	}
+	switch {
+	case prev == -1:
+		s.Append(v)
+	case index == -1:
+		_,err = s.Pop()
+	default:
+		err = s.SetAt(index, v)
+	}
+	return err
+}
+
+// decodes an encoded dict.Pair, returning its key and value.
+func decodePair(raw json.RawMessage, opts *Options) (interface{}, interface{}, error) {
+	var el element
+	if err := json.Unmarshal(raw, &el); err != nil {
+		return nil, nil, err
+	}
+	var k string
+	if err := json.Unmarshal(el.Key, &k); err != nil {
+		return nil, nil, err
+	}
+	key,err := opts.decodeKey(k)
+	if err != nil {
+		return nil, nil, err
+	}
+	v,err := opts.decode(el.Value)
+	return key, v, err
+}
+
+func applyKey(e Event, t keyed, opts *Options) error {
+	key,v,err := decodePair(e.Value, opts)
+	if err != nil {
+		return err
+	}
+	switch {
+	case e.Kind == KeyRead:
+		t.Get(key)
+	case v == nil:
+		t.Delete(key)
+	default:
+		t.Set(key, v)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package journal
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// graph is a small graph of Initiators, along with the order in which its
+// bindings and callbacks ran.
+type graph struct {
+	count reactor.Trigger
+	items slice.Trigger
+	tags dict.Trigger
+	total reactor.Indicator
+	order []string
+}
+
+func newGraph() *graph {
+	g := &graph{}
+	g.count.SetValue(0.0)
+	g.total.AddBinding(&g.count, func(v interface{}) interface{} {
+		g.order = append(g.order, "total")
+		return v.(float64) * 10
+	})
+	g.items.AddIndexWriteCallback(func(prev, v interface{}) {
+		g.order = append(g.order, fmt.Sprint("item ", prev, v))
+	})
+	g.tags.AddKeyReadCallback(func(v interface{}) {
+		g.order = append(g.order, "read tag " + strconv.Itoa(v.(dict.Pair).Key.(int)))
+	})
+	return g
+}
+
+func (g *graph) registry(sources bool) *reactor.Registry {
+	var reg reactor.Registry
+	reg.Register("count", &g.count)
+	reg.Register("items", &g.items)
+	reg.Register("tags", &g.tags)
+	if !sources {
+		reg.Register("total", &g.total)
+	}
+	return &reg
+}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	original := newGraph()
+	r := NewRecorder(&buf)
+	r.RecordRegistry(original.registry(false))
+
+	original.count.SetValue(1.0)
+	original.items.Append("a")
+	original.tags.Set(1, "x")
+	original.items.Append("b")
+	original.items.SetAt(0, "c")
+	original.tags.Get(1)
+	original.count.SetValue(2.0)
+	original.items.Pop()
+	original.tags.Delete(1)
+	r.Close()
+
+	replayed := newGraph()
+	opts := &Options{DecodeKey: func(k string) (interface{}, error) {
+		return strconv.Atoi(k)
+	}}
+	if err := Replay(&buf, replayed.registry(true), opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(replayed.order, original.order) {
+		t.Fatalf("Expected order %v; got %v", original.order, replayed.order)
+	}
+	if replayed.total.Value() != 20.0 || !reflect.DeepEqual(replayed.items.Value(), []interface{}{"c"}) {
+		t.Fatalf("Expected total 20 and items [c]; got %v and %v", replayed.total.Value(), replayed.items.Value())
+	}
+	if replayed.tags.Size() != 0 {
+		t.Fatalf("Expected tags to be empty; got %v", replayed.tags.Value())
+	}
+}
+
+func TestReplayMap(t *testing.T) {
+	var buf bytes.Buffer
+	var d dict.Trigger
+	r := NewRecorder(&buf)
+	r.Record("d", &d)
+	d.SetValue(map[interface{}]interface{}{"a": 1})
+	r.Close()
+
+	var replayed dict.Trigger
+	var reg reactor.Registry
+	reg.Register("d", &replayed)
+	if err := Replay(&buf, &reg, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if replayed.Get("a") != 1.0 {
+		t.Fatalf("Expected a to be 1; got %v", replayed.Value())
+	}
+}
+
+func TestReplayUnsupported(t *testing.T) {
+	journal := `{"s":1,"t":0,"n":"x","k":"iw","p":{"Key":-1,"Value":null},"v":{"Key":0,"Value":1}}` + "\n"
+	var trigger reactor.Trigger
+	var reg reactor.Registry
+	reg.Register("x", &trigger)
+
+	if err := Replay(strings.NewReader(journal), &reg, nil); err == nil {
+		t.Fatal("Expected error replaying index event into a reactor.Trigger")
+	}
+}
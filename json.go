@@ -0,0 +1,70 @@
+package reactor
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// decodes data into a new value of the same type as cur. If cur is nil, data 
+// is decoded as it would be into an interface{}.
+func decodeAs(data []byte, cur interface{}) (interface{}, error) {
+	if cur == nil {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+
+	p := reflect.New(reflect.TypeOf(cur))
+	if err := json.Unmarshal(data, p.Interface()); err != nil {
+		return nil, err
+	}
+	return p.Elem().Interface(), nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding the value of t as if it 
+// were not wrapped in a Trigger. The value is read as if by Value, so any 
+// read callbacks are run.
+func (t *Trigger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Value())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding data and setting t to 
+// the result as if by SetValue, so any callbacks and bindings are run. If t 
+// already has a non-nil value, data is decoded into a value of the same type. 
+// Otherwise, data is decoded as it would be into an interface{}, so numbers 
+// become float64, for example.
+func (t *Trigger) UnmarshalJSON(data []byte) error {
+	t.Lock.Lock()
+		cur := t.value
+	t.Lock.Unlock()
+
+	v,err := decodeAs(data, cur)
+	if err != nil {
+		return err
+	}
+	t.SetValue(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding the value of n as if it 
+// were not wrapped in an Indicator. The value is read as if by Value, so 
+// delayed bindings are evaluated and any read callbacks are run.
+func (n *Indicator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Value())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding data and setting n to 
+// the result as if by SetValue, so any callbacks and bindings are run. Data is 
+// decoded as described for Trigger.UnmarshalJSON.
+func (n *Indicator) UnmarshalJSON(data []byte) error {
+	n.Lock.Lock()
+		cur := n.value
+	n.Lock.Unlock()
+
+	v,err := decodeAs(data, cur)
+	if err != nil {
+		return err
+	}
+	n.SetValue(v)
+	return nil
+}
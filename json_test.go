@@ -0,0 +1,69 @@
+package reactor
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type config struct {
+	Name Trigger `json:"name"`
+	Port Trigger `json:"port"`
+	URL Indicator `json:"url"`
+}
+
+func TestTriggerMarshalJSON(t *testing.T) {
+	var c config
+	c.Name.SetValue("server")
+	c.Port.SetValue(8080)
+	c.URL.AddBinding(&c.Port, func(v interface{}) interface{} {
+		return fmt.Sprintf("http://localhost:%d", v)
+	})
+	c.Port.SetValue(8080)
+
+	data,err := json.Marshal(&c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `{"name":"server","port":8080,"url":"http://localhost:8080"}`
+	if string(data) != want {
+		t.Fatalf("Expected %s; got %s", want, data)
+	}
+}
+
+func TestTriggerUnmarshalJSON(t *testing.T) {
+	var c config
+	var written []interface{}
+	c.Port.SetValue(80)
+	c.Name.AddWriteCallback(func(prev, v interface{}) {
+		written = append(written, v)
+	})
+
+	err := json.Unmarshal([]byte(`{"name":"server","port":8080,"url":[1,2]}`), &c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if c.Name.Value() != "server" || len(written) != 1 {
+		t.Fatalf("Expected name to be set through SetValue; got %v with callbacks %v", c.Name.Value(), written)
+	}
+	if c.Port.Value() != 8080 {
+		t.Fatalf("Expected port to keep its type as int 8080; got %T %v", c.Port.Value(), c.Port.Value())
+	}
+	if v,ok := c.URL.Value().([]interface{}); !ok || len(v) != 2 || v[0] != 1.0 {
+		t.Fatalf("Expected untyped value to decode generically; got %v", c.URL.Value())
+	}
+}
+
+func TestTriggerUnmarshalJSONTypeError(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(1)
+
+	if err := json.Unmarshal([]byte(`"one"`), &trigger); err == nil {
+		t.Fatal("Expected error decoding string into int Trigger")
+	}
+	if trigger.Value() != 1 {
+		t.Fatalf("Failed decode should not change value; got %v", trigger.Value())
+	}
+}
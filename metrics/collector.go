@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// DefaultBuckets are the upper bounds of the latency histograms of a
+// Collector created by NewCollector.
+var DefaultBuckets = []time.Duration{
+	time.Microsecond,
+	10*time.Microsecond,
+	100*time.Microsecond,
+	time.Millisecond,
+	10*time.Millisecond,
+	100*time.Millisecond,
+	time.Second,
+}
+
+// series is the running total of a single Key.
+type series struct {
+	count uint64
+	total time.Duration
+	max time.Duration
+	buckets []uint64 // one per bound, and one for longer latencies
+}
+
+// Collector is a Sink that counts events and keeps a latency histogram of
+// each Key. A Collector must be created with NewCollector. It is safe to use
+// from multiple goroutines.
+type Collector struct {
+	lock sync.Mutex
+	bounds []time.Duration
+	series map[Key]*series
+}
+
+// NewCollector returns an empty Collector with histograms bounded by
+// DefaultBuckets.
+func NewCollector() *Collector {
+	return NewCollectorBuckets(DefaultBuckets)
+}
+
+// NewCollectorBuckets returns an empty Collector whose histograms count the
+// latencies up to each of bounds, which must be in increasing order, and
+// the latencies longer than every bound.
+func NewCollectorBuckets(bounds []time.Duration) *Collector {
+	return &Collector{
+		bounds: append([]time.Duration(nil), bounds...),
+		series: make(map[Key]*series),
+	}
+}
+
+// Observe records an event of k that took d.
+func (c *Collector) Observe(k Key, d time.Duration) {
+	n := sort.Search(len(c.bounds), func(i int) bool {return d <= c.bounds[i]})
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s := c.series[k]
+	if s == nil {
+		s = &series{buckets: make([]uint64, len(c.bounds)+1)}
+		c.series[k] = s
+	}
+	s.count++
+	s.total += d
+	if d > s.max {
+		s.max = d
+	}
+	s.buckets[n]++
+}
+
+// Bucket is a single bucket of a latency histogram. Count is the number of
+// events that took no longer than Le, and longer than the bound of the
+// previous bucket. The last bucket of a histogram has no bound, and an Le of
+// 0.
+type Bucket struct {
+	Le time.Duration `json:"le"`
+	Count uint64 `json:"count"`
+}
+
+// Series is the state of a single Key in a Snapshot. Durations are in
+// nanoseconds when encoded as JSON.
+type Series struct {
+	Source string `json:"source"`
+	Target string `json:"target,omitempty"`
+	SourceID uint64 `json:"source_id,omitempty"`
+	TargetID uint64 `json:"target_id,omitempty"`
+	Kind string `json:"kind"`
+	Count uint64 `json:"count"`
+	Total time.Duration `json:"total"`
+	Max time.Duration `json:"max"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Snapshot is the state of a Collector and the concurrent queues at a single
+// point in time.
+type Snapshot struct {
+	Queues []reactor.QueueStats `json:"queues"`
+	Series []Series `json:"series"`
+}
+
+// Snapshot returns the current state of c, with its series sorted by Source,
+// Target, SourceID, TargetID and Kind, along with the current state of the concurrent queues.
+func (c *Collector) Snapshot() Snapshot {
+	snap := Snapshot{Queues: reactor.Queues()}
+
+	c.lock.Lock()
+		keys := make([]Key, 0, len(c.series))
+		for k := range c.series {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a,b := keys[i], keys[j]
+			if a.Source != b.Source {
+				return a.Source < b.Source
+			}
+			if a.Target != b.Target {
+				return a.Target < b.Target
+			}
+			if a.SourceID != b.SourceID {
+				return a.SourceID < b.SourceID
+			}
+			if a.TargetID != b.TargetID {
+				return a.TargetID < b.TargetID
+			}
+			return a.Kind < b.Kind
+		})
+
+		for _,k := range keys {
+			s := c.series[k]
+			out := Series{
+				Source: k.Source,
+				Target: k.Target,
+				SourceID: k.SourceID,
+				TargetID: k.TargetID,
+				Kind: k.Kind.String(),
+				Count: s.count,
+				Total: s.total,
+				Max: s.max,
+				Buckets: make([]Bucket, len(s.buckets)),
+			}
+			for n,count := range s.buckets {
+				out.Buckets[n].Count = count
+				if n < len(c.bounds) {
+					out.Buckets[n].Le = c.bounds[n]
+				}
+			}
+			snap.Series = append(snap.Series, out)
+		}
+	c.lock.Unlock()
+	return snap
+}
+
+// Reset discards every series recorded by c.
+func (c *Collector) Reset() {
+	c.lock.Lock()
+		c.series = make(map[Key]*series)
+	c.lock.Unlock()
+}
+
+// Publish publishes the Snapshot of c through expvar under name, so it is
+// served by the /debug/vars handler of expvar. Like expvar.Publish, Publish
+// panics if name is already in use.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Snapshot()
+	}))
+}
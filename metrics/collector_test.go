@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+)
+
+func TestCollectorHistogram(t *testing.T) {
+	c := NewCollectorBuckets([]time.Duration{time.Millisecond, time.Second})
+	k := Key{Source: "a", Kind: reactor.WriteEvent}
+	c.Observe(k, time.Microsecond)
+	c.Observe(k, time.Millisecond)
+	c.Observe(k, 2*time.Millisecond)
+	c.Observe(k, time.Minute)
+
+	snap := c.Snapshot()
+	if len(snap.Series) != 1 {
+		t.Fatalf("Expected 1 series; got %v", snap.Series)
+	}
+	s := snap.Series[0]
+	if s.Count != 4 || s.Max != time.Minute || s.Kind != "write" {
+		t.Fatalf("Unexpected series %+v", s)
+	}
+	want := []Bucket{{time.Millisecond, 2}, {time.Second, 1}, {0, 1}}
+	for n,b := range want {
+		if s.Buckets[n] != b {
+			t.Fatalf("Bucket %d: Expected %+v; got %+v", n, b, s.Buckets[n])
+		}
+	}
+}
+
+func TestCollectorSnapshotOrder(t *testing.T) {
+	c := NewCollector()
+	c.Observe(Key{Source: "b", Kind: reactor.ReadEvent}, 0)
+	c.Observe(Key{Source: "a", Target: "z", Kind: reactor.BindingEvent}, 0)
+	c.Observe(Key{Source: "a", Kind: reactor.WriteEvent}, 0)
+	c.Observe(Key{Source: "a", Kind: reactor.ReadEvent}, 0)
+
+	snap := c.Snapshot()
+	got := make([]string, len(snap.Series))
+	for n,s := range snap.Series {
+		got[n] = s.Source + "/" + s.Target + "/" + s.Kind
+	}
+	want := []string{"a//read", "a//write", "a/z/binding", "b//read"}
+	for n := range want {
+		if got[n] != want[n] {
+			t.Fatalf("Expected order %v; got %v", want, got)
+		}
+	}
+
+	c.Reset()
+	if snap := c.Snapshot(); len(snap.Series) != 0 {
+		t.Fatalf("Expected no series after Reset; got %v", snap.Series)
+	}
+}
+
+var publishedCollector *Collector
+
+func TestCollectorPublish(t *testing.T) {
+	// expvar names can only be published once per process, so the published
+	// Collector is shared between runs of this test.
+	if publishedCollector == nil {
+		publishedCollector = NewCollector()
+		publishedCollector.Publish("reactor_test")
+	}
+	c := publishedCollector
+	c.Reset()
+	c.Observe(Key{Source: "a", Kind: reactor.WriteEvent}, time.Millisecond)
+
+	v := expvar.Get("reactor_test")
+	if v == nil {
+		t.Fatal("Collector was not published")
+	}
+	var snap Snapshot
+	if err := json.Unmarshal([]byte(v.String()), &snap); err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Series) != 1 || snap.Series[0].Count != 1 {
+		t.Fatalf("Unexpected published series %+v", snap.Series)
+	}
+	if len(snap.Queues) != 3 || snap.Queues[2].Name != "bind" || snap.Queues[2].Cap == 0 {
+		t.Fatalf("Unexpected published queues %+v", snap.Queues)
+	}
+}
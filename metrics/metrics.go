@@ -0,0 +1,68 @@
+// Package metrics measures the events of reactor Initiators, and the queues
+// used to run concurrent callbacks and bindings, for export to a metrics
+// backend. Events are observed by installing the Tracer returned by NewTracer,
+// either globally with reactor.SetTracer, or for single Initiators. It can be
+// installed alongside other Tracers with reactor.MultiTracer, or added to
+// single Initiators with AddTracer. Each event is passed to one or more
+// Sinks, which is the interface implemented by metrics backends.
+//
+// Collector is a Sink that keeps counts and latency histograms in memory, and
+// can publish them, along with the state of the queues, through expvar:
+//
+//	c := metrics.NewCollector()
+//	reactor.SetTracer(reactor.MultiTracer(metrics.NewTracer(c), reactor.SlogTracer(logger, slog.LevelDebug)))
+//	c.Publish("reactor")
+//
+// Events are identified by the names given to Initiators with SetName, so
+// Initiators sharing a name are counted together. Unnamed Initiators are
+// told apart by the IDs of their events instead.
+package metrics
+
+import (
+	"time"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// Key identifies a series of events: those of a single kind, of the
+// Initiator named Source. For binding events, Target is the name of the other
+// Initiator, as described for reactor.Event, so each binding is counted
+// separately. SourceID and TargetID are the IDs of the Initiators, as
+// described for reactor.Event, if they have not been named, and 0 otherwise.
+type Key struct {
+	Source string
+	Target string
+	SourceID, TargetID uint64
+	Kind reactor.EventKind
+}
+
+// Sink is the interface implemented by metrics backends. Observe is called
+// once for every traced event, with the time taken by its own callbacks,
+// watchers or BindingFunc, as for reactor.Event.Self, so the time spent in
+// the events it caused is counted against those events. The number of calls
+// for a Key is the number of times the callbacks of that Initiator were run,
+// or for binding events, the number of times that binding was recomputed.
+// Observe may be called from several goroutines at once.
+//
+// The state of the concurrent queues is not observed, since it changes
+// without events. Backends should sample it with reactor.Queues.
+type Sink interface {
+	Observe(k Key, d time.Duration)
+}
+
+// NewTracer returns a reactor.Tracer that passes every event it traces to
+// each of sinks.
+func NewTracer(sinks ...Sink) reactor.Tracer {
+	return reactor.TracerFunc(func(e reactor.Event) {
+		k := Key{Source: e.Source, Target: e.Target, Kind: e.Kind}
+		if e.Source == "" {
+			k.SourceID = e.SourceID
+		}
+		if e.Target == "" {
+			k.TargetID = e.TargetID
+		}
+		for _,s := range sinks {
+			s.Observe(k, e.Self)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// keyCounter is a Sink that counts the events of each Key.
+type keyCounter struct {
+	lock sync.Mutex
+	counts map[Key]int
+}
+
+func (k *keyCounter) Observe(key Key, d time.Duration) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.counts == nil {
+		k.counts = make(map[Key]int)
+	}
+	k.counts[key]++
+}
+
+func TestTracerFansOut(t *testing.T) {
+	var a, b keyCounter
+	tracer := NewTracer(&a, &b)
+
+	var src reactor.Trigger
+	var dst reactor.Indicator
+	src.SetName("src")
+	dst.SetName("dst")
+	src.SetTracer(tracer)
+	dst.SetTracer(tracer)
+	dst.AddBinding(&src, reactor.TrivialBinding)
+
+	src.SetValue(1)
+	src.SetValue(2)
+	dst.Value()
+
+	want := map[Key]int{
+		{Source: "src", Kind: reactor.WriteEvent}: 2,
+		{Source: "src", Target: "dst", Kind: reactor.BindingEvent}: 2,
+		{Source: "dst", Kind: reactor.WriteEvent}: 2,
+		{Source: "dst", Kind: reactor.ReadEvent}: 1,
+	}
+	for _,sink := range []*keyCounter{&a, &b} {
+		if len(sink.counts) != len(want) {
+			t.Fatalf("Expected %v; got %v", want, sink.counts)
+		}
+		for k,n := range want {
+			if sink.counts[k] != n {
+				t.Fatalf("Count of %+v: Expected %d; got %d", k, n, sink.counts[k])
+			}
+		}
+	}
+}
+
+// durations is a Sink that records the durations observed for each Key.
+type durations struct {
+	lock sync.Mutex
+	observed map[Key][]time.Duration
+}
+
+func (s *durations) Observe(key Key, d time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.observed == nil {
+		s.observed = make(map[Key][]time.Duration)
+	}
+	s.observed[key] = append(s.observed[key], d)
+}
+
+func TestTracerUnnamed(t *testing.T) {
+	var sink keyCounter
+	tracer := NewTracer(&sink)
+
+	var a, b reactor.Trigger
+	a.SetTracer(tracer)
+	b.SetTracer(tracer)
+	a.SetValue(1)
+	b.SetValue(2)
+	b.SetValue(3)
+
+	if len(sink.counts) != 2 {
+		t.Fatalf("Expected a series for each unnamed Initiator; got %v", sink.counts)
+	}
+	for k,n := range sink.counts {
+		if k.Source != "" || k.SourceID == 0 || (n != 1 && n != 2) {
+			t.Fatalf("Unexpected series %+v with %d events", k, n)
+		}
+	}
+}
+
+func TestTracerCallbackTime(t *testing.T) {
+	var sink durations
+	tracer := NewTracer(&sink)
+
+	var src reactor.Trigger
+	var dst reactor.Indicator
+	src.SetName("src")
+	dst.SetName("dst")
+	src.SetTracer(reactor.MultiTracer(tracer))
+	dst.SetTracer(tracer)
+	dst.AddBinding(&src, reactor.TrivialBinding)
+	dst.AddWriteCallback(func(prev, v interface{}) {
+		time.Sleep(10*time.Millisecond)
+	})
+	src.SetValue(1)
+
+	slow := sink.observed[Key{Source: "dst", Kind: reactor.WriteEvent}]
+	if len(slow) != 1 || slow[0] < 10*time.Millisecond {
+		t.Fatalf("Expected the callback of dst to be measured; got %v", slow)
+	}
+	for _,k := range []Key{
+		{Source: "src", Kind: reactor.WriteEvent},
+		{Source: "src", Target: "dst", Kind: reactor.BindingEvent},
+	} {
+		if d := sink.observed[k]; len(d) != 1 || d[0] >= 10*time.Millisecond {
+			t.Fatalf("Expected %+v to exclude the callback of dst; got %v", k, d)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package operators
+
+import (
+	"fmt"
+
+	"github.com/KellenWatt/reactor"
+)
+
+func Example() {
+	var input reactor.Trigger
+
+	positive := Filter(&input, func(v interface{}) bool {
+		return v.(int) > 0
+	})
+	distinct := DistinctUntilChanged(positive)
+	labels := Map(distinct, func(v interface{}) interface{} {
+		return fmt.Sprintf("reading: %d", v)
+	})
+
+	labels.AddWriteCallback(func(prev, v interface{}) {
+		fmt.Println(v)
+	})
+
+	for _,v := range []int{3, -1, 3, 5} {
+		input.SetValue(v)
+	}
+	// Output:
+	// reading: 3
+	// reading: 5
+}
@@ -0,0 +1,222 @@
+// Package operators implements combinators that derive reactor.Indicators 
+// from one or more reactor.Initiators. Each operator returns a new Indicator 
+// that is bound to its sources, so operators can be chained to build 
+// pipelines without writing bindings by hand.
+//
+// Like bindings, operators respond to changes in their sources. An Indicator 
+// returned by an operator has no value (nil) until one of its sources is 
+// written to, unless stated otherwise.
+package operators
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// Map returns an Indicator whose value is the result of calling f with the 
+// value of i whenever i changes.
+func Map(i reactor.Initiator, f reactor.BindingFunc) *reactor.Indicator {
+	var n reactor.Indicator
+	n.AddBinding(i, f)
+	return &n
+}
+
+// Filter returns an Indicator whose value follows the value of i, but only 
+// for values for which pred returns true. Other values are ignored, and the 
+// Indicator keeps its previous value.
+func Filter(i reactor.Initiator, pred func(interface{}) bool) *reactor.Indicator {
+	var n reactor.Indicator
+	n.AddBinding(i, func(v interface{}) interface{} {
+		if !pred(v) {
+			return reactor.Unchanged
+		}
+		return v
+	})
+	return &n
+}
+
+// Scan returns an Indicator whose value is an accumulation of every value of 
+// i. Whenever i changes, the accumulated value is replaced by the result of 
+// calling f with the previous accumulated value and the new value of i. The 
+// first call to f is passed seed as the accumulated value.
+func Scan(i reactor.Initiator, seed interface{}, f func(acc, v interface{}) interface{}) *reactor.Indicator {
+	var n reactor.Indicator
+	var lock sync.Mutex
+	acc := seed
+	n.AddBinding(i, func(v interface{}) interface{} {
+		lock.Lock()
+			acc = f(acc, v)
+			val := acc
+		lock.Unlock()
+		return val
+	})
+	return &n
+}
+
+// Reduce is like Scan, except the first value of i is used as the initial 
+// accumulated value, rather than a seed. f is first called when i changes 
+// for the second time.
+func Reduce(i reactor.Initiator, f func(acc, v interface{}) interface{}) *reactor.Indicator {
+	var n reactor.Indicator
+	var lock sync.Mutex
+	var acc interface{}
+	started := false
+	n.AddBinding(i, func(v interface{}) interface{} {
+		lock.Lock()
+			if started {
+				acc = f(acc, v)
+			} else {
+				acc = v
+				started = true
+			}
+			val := acc
+		lock.Unlock()
+		return val
+	})
+	return &n
+}
+
+// Merge returns an Indicator whose value is the value of whichever of sources
+// changed most recently.
+func Merge(sources ...reactor.Initiator) *reactor.Indicator {
+	var n reactor.Indicator
+	for _,i := range sources {
+		n.AddBinding(i, reactor.TrivialBinding)
+	}
+	return &n
+}
+
+// Zip returns an Indicator that combines the values of sources in order. 
+// Once every source has changed, the Indicator is set to an []interface{} 
+// containing the first unused value of each source, in the same order as 
+// sources. Values of sources that change more often than others are queued 
+// until they can be combined.
+func Zip(sources ...reactor.Initiator) *reactor.Indicator {
+	var n reactor.Indicator
+	var lock sync.Mutex
+	queues := make([][]interface{}, len(sources))
+	for index,i := range sources {
+		index := index
+		n.AddBinding(i, func(v interface{}) interface{} {
+			lock.Lock()
+				queues[index] = append(queues[index], v)
+				var val []interface{}
+				ready := true
+				for _,q := range queues {
+					ready = ready && len(q) > 0
+				}
+				if ready {
+					val = make([]interface{}, len(queues))
+					for j := range queues {
+						val[j] = queues[j][0]
+						queues[j] = queues[j][1:]
+					}
+				}
+			lock.Unlock()
+			if !ready {
+				return reactor.Unchanged
+			}
+			return val
+		})
+	}
+	return &n
+}
+
+// StartWith returns an Indicator whose value follows the value of i, but 
+// which has the value v until i first changes.
+func StartWith(i reactor.Initiator, v interface{}) *reactor.Indicator {
+	var n reactor.Indicator
+	n.SetValue(v)
+	n.AddBinding(i, reactor.TrivialBinding)
+	return &n
+}
+
+// Pairwise returns an Indicator whose value is an []interface{} containing 
+// the previous and current values of i, in that order. The Indicator is first 
+// set when i changes for the second time.
+func Pairwise(i reactor.Initiator) *reactor.Indicator {
+	var n reactor.Indicator
+	var lock sync.Mutex
+	var prev interface{}
+	started := false
+	n.AddBinding(i, func(v interface{}) interface{} {
+		lock.Lock()
+			val := []interface{}{prev, v}
+			ready := started
+			prev = v
+			started = true
+		lock.Unlock()
+		if !ready {
+			return reactor.Unchanged
+		}
+		return val
+	})
+	return &n
+}
+
+// SwitchMap returns an Indicator whose value follows an inner Initiator, 
+// chosen by calling f with the value of i whenever i changes. When the inner 
+// Initiator is chosen, the Indicator is set to its current value, and from 
+// then on follows its changes. Changes to previously chosen inner Initiators 
+// are ignored.
+//
+// Since bindings cannot be removed, each inner Initiator is bound to the 
+// Indicator once, the first time it is chosen, and that binding does nothing 
+// while it is not chosen. Inner Initiators are compared with ==, so f must 
+// return comparable values, such as pointers. If f returns nil, the Indicator 
+// keeps its value and follows no inner Initiator until f returns one.
+func SwitchMap(i reactor.Initiator, f func(interface{}) reactor.Initiator) *reactor.Indicator {
+	var n reactor.Indicator
+	var lock sync.Mutex
+	var current reactor.Initiator
+	bound := make(map[reactor.Initiator]bool)
+	n.AddBinding(i, func(v interface{}) interface{} {
+		inner := f(v)
+		lock.Lock()
+			current = inner
+			first := !bound[inner]
+			bound[inner] = true
+		lock.Unlock()
+
+		if inner == nil {
+			return reactor.Unchanged
+		}
+		if first {
+			n.AddBinding(inner, func(v interface{}) interface{} {
+				lock.Lock()
+					active := current == inner
+				lock.Unlock()
+				if !active {
+					return reactor.Unchanged
+				}
+				return v
+			})
+		}
+		return inner.Value()
+	})
+	return &n
+}
+
+// DistinctUntilChanged returns an Indicator whose value follows the value of 
+// i, but is only set when the value of i is different from the value it last 
+// took. Values are compared using reflect.DeepEqual.
+func DistinctUntilChanged(i reactor.Initiator) *reactor.Indicator {
+	var n reactor.Indicator
+	var lock sync.Mutex
+	var last interface{}
+	started := false
+	n.AddBinding(i, func(v interface{}) interface{} {
+		lock.Lock()
+			changed := !started || !reflect.DeepEqual(last, v)
+			last = v
+			started = true
+		lock.Unlock()
+		if !changed {
+			return reactor.Unchanged
+		}
+		return v
+	})
+	return &n
+}
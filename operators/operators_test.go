@@ -0,0 +1,257 @@
+package operators
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// records every value written to n
+func record(n *reactor.Indicator) *[]interface{} {
+	var values []interface{}
+	n.AddWriteCallback(func(prev, v interface{}) {
+		values = append(values, v)
+	})
+	return &values
+}
+
+func TestMap(t *testing.T) {
+	var trigger reactor.Trigger
+	n := Map(&trigger, func(v interface{}) interface{} {
+		return v.(int) * 2
+	})
+
+	trigger.SetValue(21)
+	if n.Value() != 42 {
+		t.Fatalf("Expected 42; got %v", n.Value())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var trigger reactor.Trigger
+	n := Filter(&trigger, func(v interface{}) bool {
+		return v.(int) % 2 == 0
+	})
+	got := record(n)
+
+	for i:=1; i<=5; i++ {
+		trigger.SetValue(i)
+	}
+
+	want := []interface{}{2, 4}
+	if !reflect.DeepEqual(*got, want) || n.Value() != 4 {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestScan(t *testing.T) {
+	var trigger reactor.Trigger
+	n := Scan(&trigger, 100, func(acc, v interface{}) interface{} {
+		return acc.(int) + v.(int)
+	})
+	got := record(n)
+
+	for i:=1; i<=3; i++ {
+		trigger.SetValue(i)
+	}
+
+	want := []interface{}{101, 103, 106}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	var trigger reactor.Trigger
+	n := Reduce(&trigger, func(acc, v interface{}) interface{} {
+		return acc.(int) * v.(int)
+	})
+	got := record(n)
+
+	for i:=2; i<=4; i++ {
+		trigger.SetValue(i)
+	}
+
+	want := []interface{}{2, 6, 24}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var t1,t2 reactor.Trigger
+	n := Merge(&t1, &t2)
+	got := record(n)
+
+	t1.SetValue("a")
+	t2.SetValue("b")
+	t1.SetValue("c")
+
+	want := []interface{}{"a", "b", "c"}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestZip(t *testing.T) {
+	var t1,t2 reactor.Trigger
+	n := Zip(&t1, &t2)
+	got := record(n)
+
+	t1.SetValue(1)
+	t1.SetValue(2)
+	t2.SetValue("a")
+	t1.SetValue(3)
+	t2.SetValue("b")
+
+	want := []interface{}{[]interface{}{1, "a"}, []interface{}{2, "b"}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestStartWith(t *testing.T) {
+	var trigger reactor.Trigger
+	n := StartWith(&trigger, "initial")
+
+	if n.Value() != "initial" {
+		t.Fatalf("Expected initial; got %v", n.Value())
+	}
+
+	trigger.SetValue("next")
+	if n.Value() != "next" {
+		t.Fatalf("Expected next; got %v", n.Value())
+	}
+}
+
+func TestPairwise(t *testing.T) {
+	var trigger reactor.Trigger
+	n := Pairwise(&trigger)
+	got := record(n)
+
+	for i:=1; i<=3; i++ {
+		trigger.SetValue(i)
+	}
+
+	want := []interface{}{[]interface{}{1, 2}, []interface{}{2, 3}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestSwitchMap(t *testing.T) {
+	var selector, a, b reactor.Trigger
+	sources := map[string]*reactor.Trigger{"a": &a, "b": &b}
+	a.SetValue("a0")
+	b.SetValue("b0")
+
+	n := SwitchMap(&selector, func(v interface{}) reactor.Initiator {
+		return sources[v.(string)]
+	})
+	got := record(n)
+
+	selector.SetValue("a")
+	a.SetValue("a1")
+	selector.SetValue("b")
+	a.SetValue("a2")
+	b.SetValue("b1")
+
+	want := []interface{}{"a0", "a1", "b0", "b1"}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+// countedTrigger is a Trigger that counts the Binders added to it.
+type countedTrigger struct {
+	reactor.Trigger
+	binders int
+}
+
+func (c *countedTrigger) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	c.binders++
+	c.Trigger.AddBinder(b, f, concurrent)
+}
+
+func TestSwitchMapBindsInnerOnce(t *testing.T) {
+	var selector reactor.Trigger
+	var a, b countedTrigger
+	sources := map[string]*countedTrigger{"a": &a, "b": &b}
+
+	n := SwitchMap(&selector, func(v interface{}) reactor.Initiator {
+		return sources[v.(string)]
+	})
+	got := record(n)
+
+	for i:=0; i<50; i++ {
+		selector.SetValue("a")
+		selector.SetValue("b")
+	}
+	if a.binders != 1 || b.binders != 1 {
+		t.Fatalf("Expected each inner to be bound once; got %d and %d", a.binders, b.binders)
+	}
+
+	*got = nil
+	a.SetValue("a1")
+	b.SetValue("b1")
+	want := []interface{}{"b1"}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestSwitchMapNil(t *testing.T) {
+	var selector, a reactor.Trigger
+	a.SetValue("a0")
+
+	n := SwitchMap(&selector, func(v interface{}) reactor.Initiator {
+		if v == "a" {
+			return &a
+		}
+		return nil
+	})
+	got := record(n)
+
+	selector.SetValue("a")
+	selector.SetValue("none")
+	a.SetValue("a1")
+
+	want := []interface{}{"a0"}
+	if !reflect.DeepEqual(*got, want) || n.Value() != "a0" {
+		t.Fatalf("Expected writes %v and to keep a0; got %v and %v", want, *got, n.Value())
+	}
+}
+
+func TestDistinctUntilChanged(t *testing.T) {
+	var trigger reactor.Trigger
+	n := DistinctUntilChanged(&trigger)
+	got := record(n)
+
+	for _,v := range []interface{}{1, 1, 2, 2, 1, []int{1}, []int{1}} {
+		trigger.SetValue(v)
+	}
+
+	want := []interface{}{1, 2, 1, []int{1}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Expected writes %v; got %v", want, *got)
+	}
+}
+
+func TestChain(t *testing.T) {
+	var trigger reactor.Trigger
+	evens := Filter(&trigger, func(v interface{}) bool {
+		return v.(int) % 2 == 0
+	})
+	total := Scan(evens, 0, func(acc, v interface{}) interface{} {
+		return acc.(int) + v.(int)
+	})
+
+	for i:=1; i<=6; i++ {
+		trigger.SetValue(i)
+	}
+
+	if total.Value() != 12 {
+		t.Fatalf("Expected sum of evens 12; got %v", total.Value())
+	}
+}
@@ -0,0 +1,197 @@
+package persist
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+)
+
+// Dict is a dict.Trigger whose writes are logged to a file, so its value
+// survives restarts. A Dict must be created with OpenDict, and should be
+// closed with Close when it is no longer needed.
+//
+// Every method of dict.Trigger is available, and SetValue, Set, Delete,
+// CompareAndSet and Update log the changes they make. Only the changed key is
+// logged by Set and Delete. Keys are stored as strings, as described for
+// dict.EncodeKey, and converted back with Options.DecodeKey. Changes are
+// logged before they are applied, and a change that cannot be logged, such
+// as one to a key that cannot be encoded, is not made at all. The error is
+// returned by Err.
+type Dict struct {
+	dict.Trigger
+	wal *wal
+}
+
+// OpenDict opens the Dict logged at path, creating the log if it does not
+// exist. The value of the Dict is rebuilt from the log before OpenDict
+// returns, without running any callbacks, since none can be registered yet.
+func OpenDict(path string, opts *Options) (*Dict, error) {
+	t := &Dict{}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	restore := func(raw json.RawMessage) error {
+		var pairs map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &pairs); err != nil {
+			return err
+		}
+		m := make(map[interface{}]interface{}, len(pairs))
+		for k,e := range pairs {
+			key,err := opts.decodeKey(k)
+			if err != nil {
+				return err
+			}
+			if m[key],err = opts.decode(e); err != nil {
+				return err
+			}
+		}
+		t.Trigger.SetValue(m)
+		return nil
+	}
+	apply := func(r *record) error {
+		switch r.Op {
+		case opSet:
+			return restore(r.Value)
+		case opPut:
+			key,err := opts.decodeKey(r.Key)
+			if err != nil {
+				return err
+			}
+			v,err := opts.decode(r.Value)
+			if err != nil {
+				return err
+			}
+			t.Trigger.Set(key, v)
+			return nil
+		case opDelete:
+			key,err := opts.decodeKey(r.Key)
+			if err != nil {
+				return err
+			}
+			t.Trigger.Delete(key)
+			return nil
+		}
+		return fmt.Errorf("unknown operation %q", r.Op)
+	}
+
+	w,err := openWAL(path, opts, &dictState{}, restore, apply)
+	if err != nil {
+		return nil, err
+	}
+	t.wal = w
+	return t, nil
+}
+
+// encodes m to be logged as the whole value of t, recording any error.
+func (t *Dict) encodeValue(m interface{}) (map[string]interface{}, error) {
+	n,err := dict.EncodeMap(m.(map[interface{}]interface{}))
+	if err != nil {
+		t.wal.report(err)
+	}
+	return n, err
+}
+
+// SetValue logs v, and then sets the value of t, as described for
+// dict.Trigger.SetValue. If v cannot be logged, the value of t is not
+// changed.
+func (t *Dict) SetValue(v interface{}) {
+	n,err := t.encodeValue(v)
+	if err != nil {
+		return
+	}
+	t.wal.write(opSet, 0, "", n, func() {
+		t.Trigger.SetValue(v)
+	})
+}
+
+// CompareAndSet logs v and sets the value of t, as described for
+// dict.Trigger.CompareAndSet, if the version of t is still version. If v
+// cannot be logged, the value of t is not changed, and CompareAndSet reports
+// false. If the embedded dict.Trigger is written directly after v has been
+// logged, the write of v fails, and that is logged as well.
+func (t *Dict) CompareAndSet(version uint64, v interface{}) bool {
+	n,err := t.encodeValue(v)
+	if err != nil {
+		return false
+	}
+	current := func() bool {
+		return t.Trigger.Version() == version
+	}
+	return t.wal.compareAndWrite(current, n, func() bool {
+		return t.Trigger.CompareAndSet(version, v)
+	})
+}
+
+// Update sets the value of t, as described for dict.Trigger.Update, logging
+// the result of f before it is applied. If the result cannot be logged, t is
+// not written at all, and a copy of its current value is returned.
+func (t *Dict) Update(f func(interface{}) interface{}) interface{} {
+	t.wal.order.Lock()
+	var v interface{}
+	t.wal.run(func() {
+		v = t.Trigger.Update(func(prev interface{}) interface{} {
+			next := f(prev)
+			if next == reactor.Unchanged {
+				return next
+			}
+			n,err := t.encodeValue(next)
+			if err != nil || t.wal.log(opSet, 0, "", n) != nil {
+				return reactor.Unchanged
+			}
+			return next
+		})
+	})
+	return v
+}
+
+// logs a change to key, using op, and applies it with apply if it was logged.
+func (t *Dict) writeKey(op string, key, v interface{}, apply func()) {
+	k,err := dict.EncodeKey(key)
+	if err != nil {
+		t.wal.report(err)
+		return
+	}
+	t.wal.write(op, 0, k, v, apply)
+}
+
+// Set logs the key and value, and then sets the value of key, as described
+// for dict.Trigger.Set. If they cannot be logged, the value is not set.
+func (t *Dict) Set(key, value interface{}) {
+	t.writeKey(opPut, key, value, func() {
+		t.Trigger.Set(key, value)
+	})
+}
+
+// Delete logs the removal of key, and then removes it, as described for
+// dict.Trigger.Delete. If the removal cannot be logged, key is not removed.
+func (t *Dict) Delete(key interface{}) {
+	t.writeKey(opDelete, key, nil, func() {
+		t.Trigger.Delete(key)
+	})
+}
+
+// Err returns the first error encountered writing the log of t, or nil if
+// there has not been one.
+func (t *Dict) Err() error {
+	return t.wal.error()
+}
+
+// Sync flushes the log of t to stable storage, regardless of its SyncPolicy.
+func (t *Dict) Sync() error {
+	return t.wal.sync()
+}
+
+// Compact replaces the snapshot of t with the value rebuilt from its log, and
+// empties the log. No read callbacks are run.
+func (t *Dict) Compact() error {
+	return t.wal.compact()
+}
+
+// Close flushes and closes the log of t. Writes to t after Close cannot be
+// logged, so they do not change its value, and Err returns ErrClosed.
+func (t *Dict) Close() error {
+	return t.wal.close()
+}
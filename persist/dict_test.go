@@ -0,0 +1,49 @@
+package persist
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDict(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	opts := &Options{DecodeKey: func(k string) (interface{}, error) {
+		return strconv.Atoi(k)
+	}}
+
+	d,err := OpenDict(path, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	d.SetValue(map[interface{}]interface{}{1: "one", 2: "two"})
+	d.Set(3, "three")
+	d.Set(4, nil)
+	d.Delete(1)
+	d.Close()
+
+	d,err = OpenDict(path, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[interface{}]interface{}{2: "two", 3: "three", 4: nil}
+	if !reflect.DeepEqual(d.Value(), want) {
+		t.Fatalf("Expected %v; got %v", want, d.Value())
+	}
+
+	if err := d.Compact(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	d.Close()
+
+	d,err = OpenDict(path, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer d.Close()
+	if !reflect.DeepEqual(d.Value(), want) {
+		t.Fatalf("Expected %v after compaction; got %v", want, d.Value())
+	}
+}
@@ -0,0 +1,102 @@
+// Package persist implements Triggers whose values survive restarts. Every
+// write to a persistent Trigger is appended to a write-ahead log file, and
+// when the Trigger is opened again, its value is rebuilt from that log. The
+// log is periodically compacted into a snapshot of the current value, so it
+// does not grow without bound.
+//
+// The log is a file of JSON lines, one per write, and the snapshot is a single
+// JSON document stored alongside it, at the same path with ".snapshot"
+// appended. Values must therefore be encodable with encoding/json, and are
+// decoded as they would be into an interface{}, unless Options.Decode is set.
+//
+// Entries are logged before each write is applied, and a write that cannot be
+// logged is not applied at all, so no callback or binding ever acts on a
+// write that could be lost. Each write is logged and applied while holding a
+// lock of its persistent Trigger, so writes from several goroutines are
+// applied in the order they are logged. Callbacks and bindings run once the
+// write has been applied and the lock released, as if by reactor.Batch, so
+// they may write to the same Trigger. Writes made through the embedded
+// Trigger, including those made through its views and JSON methods, are not
+// logged.
+package persist
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/KellenWatt/reactor/dict"
+)
+
+// ErrClosed is the error recorded when a persistent Trigger is written to
+// after it has been closed.
+var ErrClosed = errors.New("persist: log is closed")
+
+// SyncPolicy determines how often the log is flushed to stable storage with
+// fsync.
+type SyncPolicy int
+
+const (
+	// SyncAlways flushes the log after every write. This is the slowest
+	// policy, but no acknowledged write is lost if the system crashes.
+	SyncAlways SyncPolicy = iota
+
+	// SyncPeriodic flushes the log every Options.SyncInterval, if it has
+	// been written to since it was last flushed. Writes made since the last
+	// flush may be lost if the system crashes.
+	SyncPeriodic
+
+	// SyncNever leaves flushing the log to the operating system. Writes are
+	// not lost if the process crashes, but may be if the system does.
+	SyncNever
+)
+
+// DefaultSyncInterval is the interval used by SyncPeriodic when
+// Options.SyncInterval is not set.
+const DefaultSyncInterval = time.Second
+
+// Options configures how a persistent Trigger logs its writes. A nil *Options
+// is equivalent to the zero value, which syncs after every write and never
+// compacts the log automatically.
+type Options struct {
+	// Sync is the SyncPolicy of the log.
+	Sync SyncPolicy
+
+	// SyncInterval is the interval at which the log is flushed, when Sync is
+	// SyncPeriodic. If it is not positive, DefaultSyncInterval is used.
+	SyncInterval time.Duration
+
+	// CompactAfter is the number of entries in the log after which it is
+	// compacted into a snapshot. If it is not positive, the log is only
+	// compacted by calls to Compact.
+	CompactAfter int
+
+	// Decode converts a value read from the log or snapshot back into the
+	// value that was written. For slices, it is called once per element, and
+	// for dicts, once per value. If Decode is nil, values are decoded as they
+	// would be into an interface{}.
+	Decode func(json.RawMessage) (interface{}, error)
+
+	// DecodeKey converts the keys of a Dict, which are stored as strings as
+	// described for dict.EncodeKey. If DecodeKey is nil, keys are left as
+	// strings.
+	DecodeKey dict.KeyDecoder
+}
+
+// decodes raw with the Decode option of o.
+func (o *Options) decode(raw json.RawMessage) (interface{}, error) {
+	if o.Decode != nil {
+		return o.Decode(raw)
+	}
+	var v interface{}
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// decodes k with the DecodeKey option of o.
+func (o *Options) decodeKey(k string) (interface{}, error) {
+	if o.DecodeKey != nil {
+		return o.DecodeKey(k)
+	}
+	return k, nil
+}
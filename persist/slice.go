@@ -0,0 +1,184 @@
+package persist
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// Slice is a slice.Trigger whose writes are logged to a file, so its value
+// survives restarts. A Slice must be created with OpenSlice, and should be
+// closed with Close when it is no longer needed.
+//
+// Every method of slice.Trigger is available, and SetValue, SetAt, Append,
+// Pop, CompareAndSet and Update log the changes they make before applying
+// them. Only the changed element is logged by SetAt, Append and Pop. A change
+// that cannot be logged is not made at all, and the error is returned by Err,
+// as well as by SetAt and Pop.
+type Slice struct {
+	slice.Trigger
+	wal *wal
+}
+
+// OpenSlice opens the Slice logged at path, creating the log if it does not
+// exist. The value of the Slice is rebuilt from the log before OpenSlice
+// returns, without running any callbacks, since none can be registered yet.
+func OpenSlice(path string, opts *Options) (*Slice, error) {
+	s := &Slice{}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	restore := func(raw json.RawMessage) error {
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return err
+		}
+		v := make([]interface{}, len(elems))
+		for n,e := range elems {
+			var err error
+			if v[n],err = opts.decode(e); err != nil {
+				return err
+			}
+		}
+		s.Trigger.SetValue(v)
+		return nil
+	}
+	apply := func(r *record) error {
+		switch r.Op {
+		case opSet:
+			return restore(r.Value)
+		case opSetAt:
+			v,err := opts.decode(r.Value)
+			if err != nil {
+				return err
+			}
+			return s.Trigger.SetAt(r.Index, v)
+		case opAppend:
+			v,err := opts.decode(r.Value)
+			if err != nil {
+				return err
+			}
+			s.Trigger.Append(v)
+			return nil
+		case opPop:
+			_,err := s.Trigger.Pop()
+			return err
+		}
+		return fmt.Errorf("unknown operation %q", r.Op)
+	}
+
+	w,err := openWAL(path, opts, &sliceState{}, restore, apply)
+	if err != nil {
+		return nil, err
+	}
+	s.wal = w
+	return s, nil
+}
+
+// SetValue logs v, and then sets the value of s, as described for
+// slice.Trigger.SetValue. If v cannot be logged, the value of s is not
+// changed.
+func (s *Slice) SetValue(v interface{}) {
+	val := v.([]interface{})
+	s.wal.write(opSet, 0, "", val, func() {
+		s.Trigger.SetValue(val)
+	})
+}
+
+// CompareAndSet logs v and sets the value of s, as described for
+// slice.Trigger.CompareAndSet, if the version of s is still version. If v
+// cannot be logged, the value of s is not changed, and CompareAndSet reports
+// false. If the embedded slice.Trigger is written directly after v has been
+// logged, the write of v fails, and that is logged as well.
+func (s *Slice) CompareAndSet(version uint64, v interface{}) bool {
+	val := v.([]interface{})
+	current := func() bool {
+		return s.Trigger.Version() == version
+	}
+	return s.wal.compareAndWrite(current, val, func() bool {
+		return s.Trigger.CompareAndSet(version, val)
+	})
+}
+
+// Update sets the value of s, as described for slice.Trigger.Update, logging
+// the result of f before it is applied. If the result cannot be logged, s is
+// not written at all, and a copy of its current value is returned.
+func (s *Slice) Update(f func(interface{}) interface{}) interface{} {
+	s.wal.order.Lock()
+	var v interface{}
+	s.wal.run(func() {
+		v = s.Trigger.Update(func(prev interface{}) interface{} {
+			next := f(prev)
+			if next == reactor.Unchanged || s.wal.log(opSet, 0, "", next) != nil {
+				return reactor.Unchanged
+			}
+			return next
+		})
+	})
+	return v
+}
+
+// SetAt logs v, and then sets the value at index, as described for
+// slice.Trigger.SetAt. An OutOfBoundsError is returned if index is out of
+// range, and an error writing the log is returned as well, in which case the
+// value is not set.
+func (s *Slice) SetAt(index int, v interface{}) error {
+	var err error
+	logErr := s.wal.write(opSetAt, index, "", v, func() {
+		err = s.Trigger.SetAt(index, v)
+	})
+	if logErr != nil {
+		return logErr
+	}
+	return err
+}
+
+// Append logs v, and then adds it to the end of s, as described for
+// slice.Trigger.Append. If v cannot be logged, it is not added.
+func (s *Slice) Append(v interface{}) {
+	s.wal.write(opAppend, 0, "", v, func() {
+		s.Trigger.Append(v)
+	})
+}
+
+// Pop logs the removal of the last element of s, and then removes it, as
+// described for slice.Trigger.Pop. An OutOfBoundsError is returned if s is
+// empty, and an error writing the log is returned as well, in which case
+// nothing is removed.
+func (s *Slice) Pop() (interface{}, error) {
+	var v interface{}
+	var err error
+	logErr := s.wal.write(opPop, 0, "", nil, func() {
+		v,err = s.Trigger.Pop()
+	})
+	if logErr != nil {
+		return nil, logErr
+	}
+	return v, err
+}
+
+// Err returns the first error encountered writing the log of s, or nil if
+// there has not been one.
+func (s *Slice) Err() error {
+	return s.wal.error()
+}
+
+// Sync flushes the log of s to stable storage, regardless of its SyncPolicy.
+func (s *Slice) Sync() error {
+	return s.wal.sync()
+}
+
+// Compact replaces the snapshot of s with the value rebuilt from its log, and
+// empties the log. No read callbacks are run.
+func (s *Slice) Compact() error {
+	return s.wal.compact()
+}
+
+// Close flushes and closes the log of s. Writes to s after Close cannot be
+// logged, so they do not change its value, and Err returns ErrClosed.
+func (s *Slice) Close() error {
+	return s.wal.close()
+}
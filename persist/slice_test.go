@@ -0,0 +1,36 @@
+package persist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSlice(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	s,err := OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.SetValue([]interface{}{"a", "b"})
+	s.Append("c")
+	s.SetAt(0, "z")
+	s.Pop()
+	if err := s.SetAt(5, "x"); err == nil {
+		t.Fatal("Expected out of bounds error")
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Close()
+
+	s,err = OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+	if !reflect.DeepEqual(s.Value(), []interface{}{"z", "b"}) {
+		t.Fatalf("Expected [z b]; got %v", s.Value())
+	}
+}
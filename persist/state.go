@@ -0,0 +1,153 @@
+package persist
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// logState is the encoded value rebuilt from the snapshot and records of a
+// log, which is written to the next snapshot when the log is compacted. It is
+// kept apart from the value of the persistent Trigger, so that compacting the
+// log runs no read callbacks, and captures exactly the records written so far.
+//
+// check reports whether a record can be applied, and is called before the
+// record is written, so the log never holds a record it cannot replay. apply
+// must not fail for a record that passed check.
+type logState interface {
+	restore(json.RawMessage) error
+	check(*record) error
+	apply(*record)
+	encode() (json.RawMessage, error)
+}
+
+// valueState is the logState of a Trigger.
+type valueState struct {
+	value json.RawMessage
+}
+
+func (s *valueState) restore(raw json.RawMessage) error {
+	s.value = raw
+	return nil
+}
+
+func (s *valueState) check(r *record) error {
+	if r.Op != opSet {
+		return fmt.Errorf("unknown operation %q", r.Op)
+	}
+	return nil
+}
+
+func (s *valueState) apply(r *record) {
+	s.value = r.Value
+}
+
+func (s *valueState) encode() (json.RawMessage, error) {
+	if s.value == nil {
+		return json.RawMessage("null"), nil
+	}
+	return s.value, nil
+}
+
+// sliceState is the logState of a Slice, holding each element encoded.
+type sliceState struct {
+	elems []json.RawMessage
+}
+
+func (s *sliceState) restore(raw json.RawMessage) error {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err != nil {
+		return err
+	}
+	s.elems = elems
+	return nil
+}
+
+func (s *sliceState) check(r *record) error {
+	switch r.Op {
+	case opSet:
+		var elems []json.RawMessage
+		return json.Unmarshal(r.Value, &elems)
+	case opSetAt:
+		if r.Index < 0 || r.Index >= len(s.elems) {
+			return slice.NewError(r.Index)
+		}
+	case opPop:
+		if len(s.elems) == 0 {
+			return slice.NewTextError(0, "Attempt to Pop from an empty slice.")
+		}
+	case opAppend:
+	default:
+		return fmt.Errorf("unknown operation %q", r.Op)
+	}
+	return nil
+}
+
+func (s *sliceState) apply(r *record) {
+	switch r.Op {
+	case opSet:
+		s.restore(r.Value)
+	case opSetAt:
+		s.elems[r.Index] = r.Value
+	case opAppend:
+		s.elems = append(s.elems, r.Value)
+	case opPop:
+		s.elems = s.elems[:len(s.elems)-1]
+	}
+}
+
+func (s *sliceState) encode() (json.RawMessage, error) {
+	if s.elems == nil {
+		return json.RawMessage("[]"), nil
+	}
+	return json.Marshal(s.elems)
+}
+
+// dictState is the logState of a Dict, holding each value encoded, by its key
+// encoded as described for dict.EncodeKey.
+type dictState struct {
+	pairs map[string]json.RawMessage
+}
+
+func (s *dictState) restore(raw json.RawMessage) error {
+	var pairs map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &pairs); err != nil {
+		return err
+	}
+	s.pairs = pairs
+	return nil
+}
+
+func (s *dictState) check(r *record) error {
+	switch r.Op {
+	case opSet:
+		var pairs map[string]json.RawMessage
+		return json.Unmarshal(r.Value, &pairs)
+	case opPut, opDelete:
+	default:
+		return fmt.Errorf("unknown operation %q", r.Op)
+	}
+	return nil
+}
+
+func (s *dictState) apply(r *record) {
+	switch r.Op {
+	case opSet:
+		s.restore(r.Value)
+	case opPut:
+		if s.pairs == nil {
+			s.pairs = make(map[string]json.RawMessage)
+		}
+		s.pairs[r.Key] = r.Value
+	case opDelete:
+		delete(s.pairs, r.Key)
+	}
+}
+
+func (s *dictState) encode() (json.RawMessage, error) {
+	if s.pairs == nil {
+		return json.RawMessage("{}"), nil
+	}
+	return json.Marshal(s.pairs)
+}
@@ -0,0 +1,112 @@
+package persist
+
+import (
+	"encoding/json"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// Trigger is a reactor.Trigger whose writes are logged to a file, so its
+// value survives restarts. A Trigger must be created with Open, and should be
+// closed with Close when it is no longer needed.
+//
+// Every method of reactor.Trigger is available, and SetValue, CompareAndSet
+// and Update log the value written before it is applied. A value that cannot
+// be logged is not written at all, and the error is returned by Err.
+type Trigger struct {
+	reactor.Trigger
+	wal *wal
+}
+
+// Open opens the Trigger logged at path, creating the log if it does not
+// exist. The value of the Trigger is rebuilt from the log before Open
+// returns, without running any callbacks, since none can be registered yet.
+func Open(path string, opts *Options) (*Trigger, error) {
+	t := &Trigger{}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	restore := func(raw json.RawMessage) error {
+		v,err := opts.decode(raw)
+		if err != nil {
+			return err
+		}
+		t.Trigger.SetValue(v)
+		return nil
+	}
+	apply := func(r *record) error {
+		return restore(r.Value)
+	}
+
+	w,err := openWAL(path, opts, &valueState{}, restore, apply)
+	if err != nil {
+		return nil, err
+	}
+	t.wal = w
+	return t, nil
+}
+
+// SetValue logs v, and then sets the value of t, as described for
+// reactor.Trigger.SetValue. If v cannot be logged, the value of t is not
+// changed.
+func (t *Trigger) SetValue(v interface{}) {
+	t.wal.write(opSet, 0, "", v, func() {
+		t.Trigger.SetValue(v)
+	})
+}
+
+// CompareAndSet logs v and sets the value of t, as described for
+// reactor.Trigger.CompareAndSet, if the version of t is still version. If v
+// cannot be logged, the value of t is not changed, and CompareAndSet reports
+// false. If the embedded reactor.Trigger is written directly after v has been
+// logged, the write of v fails, and that is logged as well.
+func (t *Trigger) CompareAndSet(version uint64, v interface{}) bool {
+	current := func() bool {
+		return t.Trigger.Version() == version
+	}
+	return t.wal.compareAndWrite(current, v, func() bool {
+		return t.Trigger.CompareAndSet(version, v)
+	})
+}
+
+// Update sets the value of t, as described for reactor.Trigger.Update, logging
+// the result of f before it is applied. If the result cannot be logged, t is
+// not written at all, and its current value is returned.
+func (t *Trigger) Update(f func(interface{}) interface{}) interface{} {
+	t.wal.order.Lock()
+	var v interface{}
+	t.wal.run(func() {
+		v = t.Trigger.Update(func(prev interface{}) interface{} {
+			next := f(prev)
+			if next == reactor.Unchanged || t.wal.log(opSet, 0, "", next) != nil {
+				return reactor.Unchanged
+			}
+			return next
+		})
+	})
+	return v
+}
+
+// Err returns the first error encountered writing the log of t, or nil if
+// there has not been one.
+func (t *Trigger) Err() error {
+	return t.wal.error()
+}
+
+// Sync flushes the log of t to stable storage, regardless of its SyncPolicy.
+func (t *Trigger) Sync() error {
+	return t.wal.sync()
+}
+
+// Compact replaces the snapshot of t with the value rebuilt from its log, and
+// empties the log. No read callbacks are run.
+func (t *Trigger) Compact() error {
+	return t.wal.compact()
+}
+
+// Close flushes and closes the log of t. Writes to t after Close cannot be
+// logged, so they do not change its value, and Err returns ErrClosed.
+func (t *Trigger) Close() error {
+	return t.wal.close()
+}
@@ -0,0 +1,125 @@
+package persist
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTrigger(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	trigger,err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var written int
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		written += 1
+	})
+	trigger.SetValue("one")
+	trigger.Update(func(v interface{}) interface{} {
+		return v.(string) + "!"
+	})
+	if trigger.CompareAndSet(0, "stale") {
+		t.Fatal("Expected CompareAndSet with stale version to fail")
+	}
+	if written != 2 {
+		t.Fatalf("Expected 2 write callbacks; got %d", written)
+	}
+	trigger.Close()
+
+	trigger,err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer trigger.Close()
+	if trigger.Value() != "one!" {
+		t.Fatalf("Expected one!; got %v", trigger.Value())
+	}
+}
+
+func TestTriggerDecode(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	opts := &Options{Decode: func(raw json.RawMessage) (interface{}, error) {
+		var n int
+		err := json.Unmarshal(raw, &n)
+		return n, err
+	}}
+
+	trigger,_ := Open(path, opts)
+	trigger.SetValue(42)
+	trigger.Compact()
+	trigger.SetValue(43)
+	trigger.Close()
+
+	trigger,err := Open(path, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer trigger.Close()
+	if trigger.Value() != 43 {
+		t.Fatalf("Expected int 43; got %T %v", trigger.Value(), trigger.Value())
+	}
+}
+
+// racingValue writes to the embedded Trigger of t directly while it is being
+// logged, as a writer bypassing the log might.
+type racingValue struct {
+	t *Trigger
+}
+
+func (r racingValue) MarshalJSON() ([]byte, error) {
+	r.t.Trigger.SetValue("direct")
+	return json.Marshal("racing")
+}
+
+func TestTriggerCompareAndSetRace(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	trigger,err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trigger.SetValue("logged")
+	if trigger.CompareAndSet(trigger.Version(), racingValue{trigger}) {
+		t.Fatal("Expected CompareAndSet to fail after a direct write")
+	}
+	trigger.Close()
+
+	trigger,err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer trigger.Close()
+	if trigger.Value() != "logged" {
+		t.Fatalf("Expected the failed write not to be replayed; got %v", trigger.Value())
+	}
+}
+
+func TestTriggerUpdateNotLogged(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	trigger,err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trigger.SetValue("one")
+	trigger.Close()
+
+	var written int
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		written += 1
+	})
+	version := trigger.Version()
+	v := trigger.Update(func(v interface{}) interface{} {
+		return "two"
+	})
+	if v != "one" || trigger.Version() != version || written != 0 {
+		t.Fatalf("Expected no write when logging fails; got %v at version %d after %d callbacks", v, trigger.Version(), written)
+	}
+}
@@ -0,0 +1,448 @@
+package persist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// The operations recorded in the log.
+const (
+	opSet = "set"
+	opSetAt = "setat"
+	opAppend = "append"
+	opPop = "pop"
+	opPut = "put"
+	opDelete = "delete"
+)
+
+// record is a single line of the log. Seq increases by one with every record
+// written, and is used to skip records already included in a snapshot.
+type record struct {
+	Seq uint64 `json:"seq"`
+	Op string `json:"op"`
+	Index int `json:"index,omitempty"`
+	Key string `json:"key,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// snapshot is the contents of a snapshot file. Seq is the Seq of the last
+// record included in Value.
+type snapshot struct {
+	Seq uint64 `json:"seq"`
+	Value json.RawMessage `json:"value"`
+}
+
+// wal is the write-ahead log shared by every persistent Trigger. The Trigger
+// owning it supplies the logState kept for snapshots.
+//
+// order is held by the Trigger while each write is logged and applied, so
+// writes are applied in the order they are logged. lock guards the rest of
+// the wal, and is never held while callbacks run.
+type wal struct {
+	order sync.Mutex
+
+	lock sync.Mutex
+	path string
+	opts Options
+	file *os.File
+	size int64 // length of the log, up to the end of the last record
+	seq uint64
+	count int
+	dirty bool
+	closed bool
+	err error
+
+	state logState
+
+	done chan struct{}
+	wg sync.WaitGroup
+}
+
+// openWAL opens the log at path, creating it if it does not exist. The
+// snapshot, if any, is passed to restore, and then every record written after
+// it is passed to apply, in order. Both are also applied to state.
+//
+// A partial record at the end of the log, as left by a crash in the middle of
+// a write, is discarded. Any other record that cannot be read is an error.
+func openWAL(path string, opts *Options, state logState, restore func(json.RawMessage) error, apply func(*record) error) (*wal, error) {
+	w := &wal{path: path, state: state}
+	if opts != nil {
+		w.opts = *opts
+	}
+
+	data,err := ioutil.ReadFile(w.snapshotPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var s snapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("persist: reading snapshot %s: %v", w.snapshotPath(), err)
+		}
+		if err := restore(s.Value); err != nil {
+			return nil, err
+		}
+		if err := state.restore(s.Value); err != nil {
+			return nil, fmt.Errorf("persist: reading snapshot %s: %v", w.snapshotPath(), err)
+		}
+		w.seq = s.Seq
+	}
+
+	good,err := w.replay(apply)
+	if err != nil {
+		return nil, err
+	}
+
+	w.file,err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if info,err := w.file.Stat(); err == nil && info.Size() > good {
+		if err := w.file.Truncate(good); err != nil {
+			w.file.Close()
+			return nil, err
+		}
+	}
+	w.size = good
+
+	if w.opts.Sync == SyncPeriodic {
+		interval := w.opts.SyncInterval
+		if interval <= 0 {
+			interval = DefaultSyncInterval
+		}
+		w.done = make(chan struct{})
+		w.wg.Add(1)
+		go w.syncEvery(interval)
+	}
+	return w, nil
+}
+
+func (w *wal) snapshotPath() string {
+	return w.path + ".snapshot"
+}
+
+// reads every record in the log, passing those not yet included in the
+// snapshot to apply. Returns the length of the log up to the end of the last
+// complete record.
+func (w *wal) replay(apply func(*record) error) (int64, error) {
+	f,err := os.Open(w.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var good int64
+	for line := 1; ; line++ {
+		data,err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// Anything after the last newline is a partial record.
+			return good, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		good += int64(len(data))
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return 0, fmt.Errorf("persist: reading %s, line %d: %v", w.path, line, err)
+		}
+		w.count++
+		if rec.Seq <= w.seq {
+			continue
+		}
+		err = w.state.check(&rec)
+		if err == nil {
+			err = apply(&rec)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("persist: replaying %s, line %d: %v", w.path, line, err)
+		}
+		w.state.apply(&rec)
+		w.seq = rec.Seq
+	}
+}
+
+// records err as the error of w, if there is not one already. Must be called
+// with the lock of w held.
+func (w *wal) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// records err as the error of w, as for fail, acquiring the lock of w.
+func (w *wal) report(err error) {
+	w.lock.Lock()
+		w.fail(err)
+	w.lock.Unlock()
+}
+
+// appends a record of op to the log, along with v, unless op has no value,
+// and applies it to the state of w. Must be called with the order lock of w
+// held, before the write is applied to the Trigger.
+//
+// A record that fails the check of the state is not written, and its error is
+// returned. Any other error is returned, and recorded to be returned by Err.
+// A record that could not be written in full is removed from the log again.
+func (w *wal) log(op string, index int, key string, v interface{}) error {
+	var raw json.RawMessage
+	if op != opPop && op != opDelete {
+		var err error
+		if raw,err = json.Marshal(v); err != nil {
+			w.report(err)
+			return err
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		w.fail(ErrClosed)
+		return ErrClosed
+	}
+	rec := record{Seq: w.seq+1, Op: op, Index: index, Key: key, Value: raw}
+	if err := w.state.check(&rec); err != nil {
+		return err
+	}
+	data,err := json.Marshal(rec)
+	if err != nil {
+		w.fail(err)
+		return err
+	}
+	data = append(data, '\n')
+	if _,err := w.file.Write(data); err != nil {
+		w.file.Truncate(w.size)
+		w.fail(err)
+		return err
+	}
+	if w.opts.Sync == SyncAlways {
+		if err := w.file.Sync(); err != nil {
+			w.file.Truncate(w.size)
+			w.fail(err)
+			return err
+		}
+	}
+
+	w.state.apply(&rec)
+	w.seq = rec.Seq
+	w.size += int64(len(data))
+	w.dirty = w.opts.Sync != SyncAlways
+	w.count++
+	return nil
+}
+
+// runs apply, which applies a write that has been logged, as if by
+// reactor.Batch, and releases the order lock of w once it returns, before the
+// callbacks and bindings it held back are run. Those can therefore write to
+// the Trigger again, or compact it. The log is compacted afterwards, if it
+// has reached the CompactAfter option.
+func (w *wal) run(apply func()) {
+	reactor.Batch(func() {
+		defer w.order.Unlock()
+		apply()
+	})
+
+	w.lock.Lock()
+		compact := w.opts.CompactAfter > 0 && w.count >= w.opts.CompactAfter
+	w.lock.Unlock()
+	if compact {
+		if err := w.compact(); err != nil {
+			w.report(err)
+		}
+	}
+}
+
+// logs a write of op, as for log, and if it was logged, applies it with
+// apply, as for run. Errors are returned as for log, and apply is not run.
+func (w *wal) write(op string, index int, key string, v interface{}, apply func()) error {
+	w.order.Lock()
+	if err := w.log(op, index, key, v); err != nil {
+		w.order.Unlock()
+		return err
+	}
+	w.run(apply)
+	return nil
+}
+
+// logs a record setting the whole value to v, as for write, if current
+// reports that the version apply checks against is still current, and then
+// runs apply, which reports whether it made the write. Writes to the Trigger
+// that bypass w can still change its version in between, in which case a
+// record restoring the state logged before is written, so that replaying the
+// log does not apply a write that was never made.
+func (w *wal) compareAndWrite(current func() bool, v interface{}, apply func() bool) bool {
+	w.order.Lock()
+	if !current() {
+		w.order.Unlock()
+		return false
+	}
+	w.lock.Lock()
+		before,err := w.state.encode()
+	w.lock.Unlock()
+	if err != nil {
+		w.report(err)
+		w.order.Unlock()
+		return false
+	}
+	if w.log(opSet, 0, "", v) != nil {
+		w.order.Unlock()
+		return false
+	}
+
+	var ok bool
+	w.run(func() {
+		if ok = apply(); !ok {
+			w.log(opSet, 0, "", before)
+		}
+	})
+	return ok
+}
+
+// writes the state of w to a new snapshot, replacing any existing one, and
+// empties the log. The snapshot is written to a temporary file first, so a
+// crash leaves either the old snapshot or the new one in place.
+func (w *wal) compact() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+
+	raw,err := w.state.encode()
+	if err != nil {
+		return err
+	}
+	data,err := json.Marshal(snapshot{Seq: w.seq, Value: raw})
+	if err != nil {
+		return err
+	}
+	tmp := w.snapshotPath() + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, w.snapshotPath()); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(w.path)); err != nil {
+		return err
+	}
+
+	// Records left behind by a crash before this point are skipped on
+	// replay, since they are included in the snapshot.
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.size = 0
+	w.count = 0
+	w.dirty = false
+	return nil
+}
+
+// flushes the log to stable storage.
+func (w *wal) sync() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	w.dirty = false
+	return w.file.Sync()
+}
+
+func (w *wal) syncEvery(interval time.Duration) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.lock.Lock()
+			if w.dirty && !w.closed {
+				w.dirty = false
+				if err := w.file.Sync(); err != nil {
+					w.fail(err)
+				}
+			}
+			w.lock.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// flushes and closes the log. Closing a closed log does nothing.
+func (w *wal) close() error {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
+	w.closed = true
+	err := w.file.Sync()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	w.lock.Unlock()
+
+	if w.done != nil {
+		close(w.done)
+		w.wg.Wait()
+	}
+	return err
+}
+
+// returns the first error encountered by w.
+func (w *wal) error() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.err
+}
+
+func writeFileSync(path string, data []byte) error {
+	f,err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _,err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// flushes the directory at path, so that a rename within it is durable. Not
+// every platform supports this, so errors from Sync itself are ignored.
+func syncDir(path string) error {
+	d,err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	d.Sync()
+	return d.Close()
+}
@@ -0,0 +1,237 @@
+package persist
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// returns the path of a log in a new temporary directory, and a function
+// that removes the directory.
+func tempLog(t *testing.T) (string, func()) {
+	dir,err := ioutil.TempDir("", "persist")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return filepath.Join(dir, "state.log"), func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestWALPartialRecord(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	trigger,err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trigger.SetValue("a")
+	trigger.SetValue("b")
+	trigger.Close()
+
+	f,_ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"seq":3,"op":"set","val`)
+	f.Close()
+
+	trigger,err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Expected partial record to be discarded; got error: %v", err)
+	}
+	if trigger.Value() != "b" {
+		t.Fatalf("Expected b; got %v", trigger.Value())
+	}
+	trigger.SetValue("c")
+	trigger.Close()
+
+	trigger,err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer trigger.Close()
+	if trigger.Value() != "c" {
+		t.Fatalf("Expected c after writing past a partial record; got %v", trigger.Value())
+	}
+}
+
+func TestWALCorruptRecord(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	ioutil.WriteFile(path, []byte("{\"seq\":1,\"op\":\"set\",\"value\":1}\nnot json\n"), 0644)
+	if _,err := Open(path, nil); err == nil {
+		t.Fatal("Expected error for corrupt record")
+	}
+}
+
+func TestWALCompaction(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	s,err := OpenSlice(path, &Options{CompactAfter: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for n:=0; n<4; n++ {
+		s.Append(float64(n))
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Close()
+
+	data,_ := ioutil.ReadFile(path)
+	if len(data) == 0 {
+		t.Fatal("Expected the entry after compaction to be logged")
+	}
+	if _,err := os.Stat(path + ".snapshot"); err != nil {
+		t.Fatalf("Expected snapshot: %v", err)
+	}
+
+	s,err = OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+	if s.Size() != 4 {
+		t.Fatalf("Expected 4 elements; got %v", s.Value())
+	}
+}
+
+func TestWALCompactionCrash(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	s,err := OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Append("a")
+	s.Append("b")
+	s.Close()
+
+	// A crash after the snapshot is written, but before the log is emptied,
+	// leaves records that are already in the snapshot.
+	log,_ := ioutil.ReadFile(path)
+	s,_ = OpenSlice(path, nil)
+	s.Compact()
+	s.Close()
+	ioutil.WriteFile(path, log, 0644)
+
+	s,err = OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+	if s.Size() != 2 {
+		t.Fatalf("Expected records in the snapshot to be skipped; got %v", s.Value())
+	}
+}
+
+func TestWALSyncPeriodic(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	trigger,err := Open(path, &Options{Sync: SyncPeriodic, SyncInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trigger.SetValue(1)
+	time.Sleep(10*time.Millisecond)
+
+	if err := trigger.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trigger.SetValue(2)
+	if trigger.Err() != ErrClosed {
+		t.Fatalf("Expected ErrClosed; got %v", trigger.Err())
+	}
+	if trigger.Value() != 1 {
+		t.Fatalf("Expected a write that was not logged to be discarded; got %v", trigger.Value())
+	}
+}
+
+func TestWALLogsBeforeApplying(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	trigger,err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer trigger.Close()
+	var logged bool
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		data,_ := ioutil.ReadFile(path)
+		logged = strings.Contains(string(data), `"value":"a"`)
+	})
+	trigger.SetValue("a")
+	if !logged {
+		t.Fatal("Expected the write to be logged before its callbacks ran")
+	}
+}
+
+func TestWALWriteFromCallback(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	s,err := OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.AddIndexWriteCallback(func(prev, v interface{}) {
+		if v.(slice.Index).Value == "x" {
+			s.Append("y")
+		}
+	})
+	s.Append("x")
+	s.Close()
+
+	s,err = OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+	if !reflect.DeepEqual(s.Value(), []interface{}{"x", "y"}) {
+		t.Fatalf("Expected [x y]; got %v", s.Value())
+	}
+}
+
+func TestWALCompactFromCallback(t *testing.T) {
+	path,cleanup := tempLog(t)
+	defer cleanup()
+
+	s,err := OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reads := 0
+	s.AddReadCallback(func(v interface{}) {
+		reads++
+	})
+	s.AddIndexWriteCallback(func(prev, v interface{}) {
+		if err := s.Compact(); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+	s.Append("x")
+	s.Close()
+	if reads != 0 {
+		t.Fatalf("Expected compaction not to run read callbacks; ran %d", reads)
+	}
+
+	s,err = OpenSlice(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+	if !reflect.DeepEqual(s.Value(), []interface{}{"x"}) {
+		t.Fatalf("Expected [x]; got %v", s.Value())
+	}
+}
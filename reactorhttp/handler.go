@@ -0,0 +1,456 @@
+// Package reactorhttp serves a reactor.Registry over HTTP. Each Initiator in
+// the Registry is a resource named by its path, whose value can be read with
+// GET and written with PUT, using JSON. Elements of slice Triggers and keys of
+// dict Triggers are resources of their own, and changes to any Initiator that
+// implements reactor.Watcher can be streamed as Server-Sent Events.
+//
+// The routes served by a Handler are as follows, relative to the path it is
+// mounted at:
+//
+//	GET    /                  names of every Initiator, as a JSON array
+//	GET    /{name}            Value
+//	PUT    /{name}            SetValue
+//	GET    /{name}/{index}    At, for slices
+//	PUT    /{name}/{index}    SetAt, for slices
+//	POST   /{name}            Append, for slices
+//	DELETE /{name}            Pop, for slices
+//	GET    /{name}/{key}      GetCheck, for dicts
+//	PUT    /{name}/{key}      Set, for dicts
+//	DELETE /{name}/{key}      Delete, for dicts
+//
+// A GET request for /{name} that accepts text/event-stream receives the
+// current value as a "value" event, and then an event for every write, until
+// the client disconnects. Writes to the whole value are sent as "change"
+// events, writes to single elements of slices, such as by SetAt, Append and
+// Pop, as "index-change" events, and writes to single keys of dicts, such as
+// by Set and Delete, as "key-change" events. The data of each event is a JSON
+// object with "prev" and "value" fields, holding the values passed to the
+// matching write callbacks: the whole values, or slice.Index or dict.Pair
+// structs. Events of different kinds are not necessarily sent in the order
+// the writes were made.
+//
+// Names and keys are single path segments, and may be escaped. Maps used by
+// dict Triggers are encoded with string keys, as described for
+// dict.EncodeKey.
+package reactorhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+)
+
+// Interfaces for the methods used by index and key routes.
+type indexed interface {
+	At(int) (interface{}, error)
+	SetAt(int, interface{}) error
+	Append(interface{})
+	Pop() (interface{}, error)
+}
+
+type keyed interface {
+	GetCheck(interface{}) (interface{}, bool)
+	Set(interface{}, interface{})
+	Delete(interface{})
+}
+
+// Interfaces for the element-level changes sent by event streams.
+type indexWatcher interface {
+	WatchIndex(context.Context, reactor.WatchMode) <-chan reactor.Change
+}
+
+type keyWatcher interface {
+	WatchKey(context.Context, reactor.WatchMode) <-chan reactor.Change
+}
+
+// Handler is an http.Handler that serves the Initiators in a Registry. A
+// Handler should be created with NewHandler, and its options set before it
+// starts serving requests.
+type Handler struct {
+	registry *reactor.Registry
+
+	// Decode converts the body of a PUT or POST request into the value to
+	// write. For index and key routes, it is called with the element value.
+	// If Decode is nil, values are decoded as they would be into an
+	// interface{}, so numbers become float64, for example.
+	Decode func(name string, data []byte) (interface{}, error)
+
+	// DecodeKey converts the keys of dict Triggers, both in paths and in
+	// maps written with PUT. If DecodeKey is nil, keys are left as strings.
+	DecodeKey dict.KeyDecoder
+
+	// Mode is the WatchMode of event streams. A Buffered mode keeps every
+	// change for a client, but blocks writers to the Initiator while a slow
+	// client's buffer is full, so it should only be used with trusted clients.
+	Mode reactor.WatchMode
+
+	// MaxBodySize is the largest request body, in bytes, that is read.
+	// Requests with larger bodies fail with 413 Request Entity Too Large. If
+	// MaxBodySize is zero or less, bodies are not limited.
+	MaxBodySize int64
+}
+
+// DefaultMaxBodySize is the MaxBodySize of a Handler created with NewHandler.
+const DefaultMaxBodySize = 1 << 20
+
+// NewHandler returns a Handler that serves the Initiators in reg. Initiators
+// added to reg later are served as well. Event streams are created with a
+// WatchMode of reactor.Latest, so a client that reads slowly misses
+// intermediate changes rather than holding up writers, and request bodies are
+// limited to DefaultMaxBodySize.
+func NewHandler(reg *reactor.Registry) *Handler {
+	return &Handler{registry: reg, Mode: reactor.Latest, MaxBodySize: DefaultMaxBodySize}
+}
+
+func (h *Handler) decode(name string, data []byte) (interface{}, error) {
+	if h.Decode != nil {
+		return h.Decode(name, data)
+	}
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+func (h *Handler) decodeKey(k string) (interface{}, error) {
+	if h.DecodeKey != nil {
+		return h.DecodeKey(k)
+	}
+	return k, nil
+}
+
+// converts v into a value that can be encoded as JSON, encoding the keys of
+// maps used by dict Triggers.
+func encodable(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		return dict.EncodeMap(v)
+	case dict.Pair:
+		key,err := dict.EncodeKey(v.Key)
+		return dict.Pair{Key: key, Value: v.Value}, err
+	}
+	return v, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	v,err := encodable(v)
+	var data []byte
+	if err == nil {
+		data,err = json.Marshal(v)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
+
+// splits the path of r into unescaped segments.
+func segments(r *http.Request) ([]string, error) {
+	path := strings.Trim(r.URL.EscapedPath(), "/")
+	if path == "" {
+		return nil, nil
+	}
+	parts := strings.Split(path, "/")
+	for n,p := range parts {
+		var err error
+		if parts[n],err = url.PathUnescape(p); err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+// ServeHTTP serves the routes described in the package documentation.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts,err := segments(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch len(parts) {
+	case 0:
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		writeJSON(w, h.registry.Names())
+		return
+	case 1, 2:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	i,ok := h.registry.Lookup(parts[0])
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 1 {
+		h.serveValue(w, r, parts[0], i)
+		return
+	}
+
+	switch x := i.(type) {
+	case indexed:
+		h.serveIndex(w, r, parts[0], x, parts[1])
+	case keyed:
+		h.serveKey(w, r, parts[0], x, parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// reads and decodes the body of r, up to MaxBodySize bytes.
+func (h *Handler) body(w http.ResponseWriter, r *http.Request, name string) (interface{}, bool) {
+	body := r.Body
+	if h.MaxBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, h.MaxBodySize)
+	}
+	data,err := ioutil.ReadAll(body)
+	if err != nil {
+		code := http.StatusBadRequest
+		// MaxBytesReader fails once the limit has been read in full.
+		if h.MaxBodySize > 0 && int64(len(data)) >= h.MaxBodySize {
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), code)
+		return nil, false
+	}
+	v,err := h.decode(name, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return v, true
+}
+
+// converts the body of a PUT to a dict Trigger to a map with decoded keys.
+func (h *Handler) decodeMap(v interface{}) (interface{}, error) {
+	m,ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object, got %T", v)
+	}
+	n := make(map[interface{}]interface{}, len(m))
+	for k,x := range m {
+		key,err := h.decodeKey(k)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = x
+	}
+	return n, nil
+}
+
+func (h *Handler) serveValue(w http.ResponseWriter, r *http.Request, name string, i reactor.Initiator) {
+	_,isSlice := i.(indexed)
+	_,isDict := i.(keyed)
+
+	switch r.Method {
+	case http.MethodGet:
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			h.serveEvents(w, r, i)
+			return
+		}
+		writeJSON(w, i.Value())
+	case http.MethodPut:
+		v,ok := h.body(w, r, name)
+		if !ok {
+			return
+		}
+		var err error
+		switch {
+		case isSlice:
+			if _,ok := v.([]interface{}); !ok {
+				err = fmt.Errorf("expected a JSON array, got %T", v)
+			}
+		case isDict:
+			v,err = h.decodeMap(v)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		i.SetValue(v)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		if !isSlice {
+			methodNotAllowed(w, http.MethodGet, http.MethodPut)
+			return
+		}
+		v,ok := h.body(w, r, name)
+		if !ok {
+			return
+		}
+		i.(indexed).Append(v)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if !isSlice {
+			methodNotAllowed(w, http.MethodGet, http.MethodPut)
+			return
+		}
+		v,err := i.(indexed).Pop()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, v)
+	default:
+		if isSlice {
+			methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete)
+			return
+		}
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+func (h *Handler) serveIndex(w http.ResponseWriter, r *http.Request, name string, s indexed, segment string) {
+	index,err := strconv.Atoi(segment)
+	if err != nil {
+		http.Error(w, "invalid index: " + segment, http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		v,err := s.At(index)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, v)
+	case http.MethodPut:
+		v,ok := h.body(w, r, name)
+		if !ok {
+			return
+		}
+		if err := s.SetAt(index, v); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+func (h *Handler) serveKey(w http.ResponseWriter, r *http.Request, name string, t keyed, segment string) {
+	key,err := h.decodeKey(segment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		v,ok := t.GetCheck(key)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, v)
+	case http.MethodPut:
+		v,ok := h.body(w, r, name)
+		if !ok {
+			return
+		}
+		t.Set(key, v)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		t.Delete(key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// change is the data of a change event.
+type change struct {
+	Prev interface{} `json:"prev"`
+	Value interface{} `json:"value"`
+}
+
+// writes a single Server-Sent Event.
+func writeEvent(w http.ResponseWriter, event string, v interface{}) error {
+	data,err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_,err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+func (h *Handler) serveEvents(w http.ResponseWriter, r *http.Request, i reactor.Initiator) {
+	watcher,ok := i.(reactor.Watcher)
+	flusher,canFlush := w.(http.Flusher)
+	if !ok || !canFlush {
+		http.Error(w, "event streams are not supported for this resource", http.StatusNotAcceptable)
+		return
+	}
+
+	// Subscribe before reading the current value, so no change is missed.
+	changes := watcher.Watch(r.Context(), h.Mode)
+	var parts <-chan reactor.Change
+	var partEvent string
+	switch x := i.(type) {
+	case indexWatcher:
+		parts, partEvent = x.WatchIndex(r.Context(), h.Mode), "index-change"
+	case keyWatcher:
+		parts, partEvent = x.WatchKey(r.Context(), h.Mode), "key-change"
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	v,err := encodable(i.Value())
+	if err == nil {
+		err = writeEvent(w, "value", v)
+	}
+	if err != nil {
+		return
+	}
+	flusher.Flush()
+
+	for {
+		var c reactor.Change
+		var ok bool
+		event := "change"
+		select {
+		case c,ok = <-changes:
+		case c,ok = <-parts:
+			event = partEvent
+		}
+		if !ok {
+			return
+		}
+
+		prev,err := encodable(c.Prev)
+		if err != nil {
+			return
+		}
+		v,err := encodable(c.Value)
+		if err != nil {
+			return
+		}
+		if err := writeEvent(w, event, change{prev, v}); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,231 @@
+package reactorhttp
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+type fixture struct {
+	setpoint reactor.Trigger
+	items slice.Trigger
+	limits dict.Trigger
+	server *httptest.Server
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{}
+	var reg reactor.Registry
+	reg.Register("setpoint", &f.setpoint)
+	reg.Register("items", &f.items)
+	reg.Register("limits", &f.limits)
+
+	h := NewHandler(&reg)
+	h.DecodeKey = func(k string) (interface{}, error) {
+		return strconv.Atoi(k)
+	}
+	f.server = httptest.NewServer(h)
+	return f
+}
+
+func (f *fixture) do(t *testing.T, method, path, body string) (int, string) {
+	req,err := http.NewRequest(method, f.server.URL + path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp,err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	data,_ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+func TestHandlerValue(t *testing.T) {
+	f := newFixture(t)
+	defer f.server.Close()
+	var written []interface{}
+	f.setpoint.AddWriteCallback(func(prev, v interface{}) {
+		written = append(written, v)
+	})
+
+	if code,body := f.do(t, "GET", "/", ""); code != 200 || body != `["items","limits","setpoint"]` {
+		t.Fatalf("Unexpected names: %d %s", code, body)
+	}
+	if code,_ := f.do(t, "PUT", "/setpoint", "21.5"); code != http.StatusNoContent {
+		t.Fatalf("Expected 204; got %d", code)
+	}
+	if !reflect.DeepEqual(written, []interface{}{21.5}) {
+		t.Fatalf("Expected PUT to go through SetValue; got %v", written)
+	}
+	if code,body := f.do(t, "GET", "/setpoint", ""); code != 200 || body != "21.5" {
+		t.Fatalf("Expected 21.5; got %d %s", code, body)
+	}
+	if code,_ := f.do(t, "PUT", "/setpoint", "{"); code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for invalid JSON; got %d", code)
+	}
+	if code,_ := f.do(t, "GET", "/missing", ""); code != http.StatusNotFound {
+		t.Fatalf("Expected 404; got %d", code)
+	}
+	if code,_ := f.do(t, "POST", "/setpoint", "1"); code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected 405; got %d", code)
+	}
+}
+
+func TestHandlerSlice(t *testing.T) {
+	f := newFixture(t)
+	defer f.server.Close()
+
+	f.do(t, "PUT", "/items", `["a","b"]`)
+	f.do(t, "POST", "/items", `"c"`)
+	if code,_ := f.do(t, "PUT", "/items/0", `"z"`); code != http.StatusNoContent {
+		t.Fatalf("Expected 204; got %d", code)
+	}
+	if code,body := f.do(t, "DELETE", "/items", ""); code != 200 || body != `"c"` {
+		t.Fatalf("Expected popped value c; got %d %s", code, body)
+	}
+	if code,body := f.do(t, "GET", "/items/1", ""); code != 200 || body != `"b"` {
+		t.Fatalf("Expected b; got %d %s", code, body)
+	}
+	if code,_ := f.do(t, "GET", "/items/5", ""); code != http.StatusNotFound {
+		t.Fatalf("Expected 404 out of range; got %d", code)
+	}
+	if code,_ := f.do(t, "PUT", "/items", `{}`); code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for non-array; got %d", code)
+	}
+	if !reflect.DeepEqual(f.items.Value(), []interface{}{"z", "b"}) {
+		t.Fatalf("Expected [z b]; got %v", f.items.Value())
+	}
+}
+
+func TestHandlerDict(t *testing.T) {
+	f := newFixture(t)
+	defer f.server.Close()
+
+	f.do(t, "PUT", "/limits", `{"1":10,"2":20}`)
+	f.do(t, "PUT", "/limits/3", `30`)
+	f.do(t, "DELETE", "/limits/1", "")
+
+	want := map[interface{}]interface{}{2: 20.0, 3: 30.0}
+	if !reflect.DeepEqual(f.limits.Value(), want) {
+		t.Fatalf("Expected %v; got %v", want, f.limits.Value())
+	}
+	if code,body := f.do(t, "GET", "/limits", ""); code != 200 || body != `{"2":20,"3":30}` {
+		t.Fatalf("Unexpected map: %d %s", code, body)
+	}
+	if code,_ := f.do(t, "GET", "/limits/1", ""); code != http.StatusNotFound {
+		t.Fatalf("Expected 404 for deleted key; got %d", code)
+	}
+	if code,_ := f.do(t, "GET", "/limits/x", ""); code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for undecodable key; got %d", code)
+	}
+}
+
+// opens an event stream for path, returning a function that reads the next
+// event, with its lines joined by "|", and a function that closes the stream.
+func (f *fixture) events(t *testing.T, path string) (func() string, func()) {
+	ctx,cancel := context.WithCancel(context.Background())
+	req,_ := http.NewRequest("GET", f.server.URL + path, nil)
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	resp,err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		cancel()
+		resp.Body.Close()
+		t.Fatalf("Expected event stream; got %s", resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		var event []string
+		for lines.Scan() {
+			if lines.Text() == "" {
+				return strings.Join(event, "|")
+			}
+			event = append(event, lines.Text())
+		}
+		t.Fatalf("Stream ended: %v", lines.Err())
+		return ""
+	}
+	return next, func() {
+		cancel()
+		resp.Body.Close()
+	}
+}
+
+func TestHandlerEvents(t *testing.T) {
+	f := newFixture(t)
+	defer f.server.Close()
+	f.setpoint.SetValue(1)
+
+	next,done := f.events(t, "/setpoint")
+	defer done()
+
+	if e := next(); e != "event: value|data: 1" {
+		t.Fatalf("Unexpected initial event: %s", e)
+	}
+	f.setpoint.SetValue(2)
+	if e := next(); e != `event: change|data: {"prev":1,"value":2}` {
+		t.Fatalf("Unexpected change event: %s", e)
+	}
+}
+
+func TestHandlerElementEvents(t *testing.T) {
+	f := newFixture(t)
+	defer f.server.Close()
+
+	next,done := f.events(t, "/limits")
+	defer done()
+	if e := next(); e != "event: value|data: {}" {
+		t.Fatalf("Unexpected initial event: %s", e)
+	}
+	if code,_ := f.do(t, "PUT", "/limits/3", "30"); code != http.StatusNoContent {
+		t.Fatalf("Expected 204; got %d", code)
+	}
+	if e := next(); e != `event: key-change|data: {"prev":{"Key":"3","Value":null},"value":{"Key":"3","Value":30}}` {
+		t.Fatalf("Unexpected key event: %s", e)
+	}
+
+	next,done = f.events(t, "/items")
+	defer done()
+	if e := next(); e != "event: value|data: []" {
+		t.Fatalf("Unexpected initial event: %s", e)
+	}
+	if code,_ := f.do(t, "POST", "/items", `"a"`); code != http.StatusNoContent {
+		t.Fatalf("Expected 204; got %d", code)
+	}
+	if e := next(); !strings.HasPrefix(e, "event: index-change|") {
+		t.Fatalf("Unexpected index event: %s", e)
+	}
+}
+
+func TestHandlerMaxBodySize(t *testing.T) {
+	f := newFixture(t)
+	defer f.server.Close()
+	f.server.Config.Handler.(*Handler).MaxBodySize = 8
+
+	if code,_ := f.do(t, "PUT", "/setpoint", "12345678"); code != http.StatusNoContent {
+		t.Fatalf("Expected 204 for a body at the limit; got %d", code)
+	}
+	if code,_ := f.do(t, "PUT", "/setpoint", "123456789"); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413 for a body over the limit; got %d", code)
+	}
+	if f.setpoint.Value() != 12345678.0 {
+		t.Fatalf("Expected the oversized write to be rejected; got %v", f.setpoint.Value())
+	}
+}
@@ -0,0 +1,151 @@
+package reactor
+
+import (
+	"sort"
+	"sync"
+)
+
+// Snapshot is the set of values of the Initiators in a Registry at a single
+// point in time, keyed by the name each Initiator was registered with.
+type Snapshot map[string]interface{}
+
+// Registry is a named set of Initiators, whose values can be captured
+// together with Snapshot and set again later with Restore. The zero value is
+// an empty Registry, ready to use. It is safe to use a Registry from multiple
+// goroutines.
+type Registry struct {
+	lock sync.Mutex
+	entries map[string]Initiator
+}
+
+// Register adds i to r under name, replacing any Initiator already registered
+// with that name.
+func (r *Registry) Register(name string, i Initiator) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.entries == nil {
+		r.entries = make(map[string]Initiator)
+	}
+	r.entries[name] = i
+}
+
+// Unregister removes the Initiator registered under name, if any.
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+		delete(r.entries, name)
+	r.lock.Unlock()
+}
+
+// Lookup returns the Initiator registered under name, and whether there was
+// one.
+func (r *Registry) Lookup(name string) (Initiator, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	i,ok := r.entries[name]
+	return i, ok
+}
+
+// Names returns the names of every Initiator in r, in sorted order.
+func (r *Registry) Names() []string {
+	r.lock.Lock()
+		names := make([]string, 0, len(r.entries))
+		for name := range r.entries {
+			names = append(names, name)
+		}
+	r.lock.Unlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// returns the names and Initiators of r, in sorted order by name.
+func (r *Registry) sorted() ([]string, []Initiator) {
+	names := r.Names()
+	inits := make([]Initiator, len(names))
+
+	r.lock.Lock()
+		for n,name := range names {
+			inits[n] = r.entries[name]
+		}
+	r.lock.Unlock()
+	return names, inits
+}
+
+// Snapshot returns the value of every Initiator in r, as if by Value. 
+//
+// For Initiators that implement Versioned, the values are consistent: the 
+// version of each value is recorded as it is read, and once every value has 
+// been read, each version is checked again. If any of them was written in 
+// the meantime, the snapshot is retaken, up to TransactionRetries times, 
+// after which ErrConflict is returned. Initiators that implement 
+// VersionedReader have their value and version read together. For those that 
+// only implement Versioned, the version is recorded before the value is read, 
+// so an Initiator whose Value writes to itself can never be checked 
+// successfully. Initiators that do not implement Versioned cannot be checked, 
+// so their values are only as consistent as the writers to them allow. Read 
+// callbacks and delayed bindings that write to other Initiators in r may 
+// prevent a consistent snapshot from being taken.
+//
+// The values of slice and dict Triggers are copies, so each Snapshot is 
+// isolated from later changes to them.
+func (r *Registry) Snapshot() (Snapshot, error) {
+	s,_,err := r.SnapshotVersions()
+	return s, err
+}
+
+// SnapshotVersions returns a Snapshot of r, as for Snapshot, along with the 
+// version each value was read at, keyed by name. Only Initiators that 
+// implement Versioned have a version. 
+func (r *Registry) SnapshotVersions() (Snapshot, map[string]uint64, error) {
+	names,inits := r.sorted()
+	versions := make([]uint64, len(inits))
+
+	for n:=0; n<TransactionRetries; n++ {
+		s := make(Snapshot, len(inits))
+		for k,i := range inits {
+			switch v := i.(type) {
+			case VersionedReader:
+				s[names[k]], versions[k] = v.ValueVersion()
+			case Versioned:
+				versions[k] = v.Version()
+				s[names[k]] = v.Value()
+			default:
+				s[names[k]] = i.Value()
+			}
+		}
+
+		consistent := true
+		for k,i := range inits {
+			if v,ok := i.(Versioned); ok && v.Version() != versions[k] {
+				consistent = false
+				break
+			}
+		}
+		if consistent {
+			vs := make(map[string]uint64, len(inits))
+			for k,i := range inits {
+				if _,ok := i.(Versioned); ok {
+					vs[names[k]] = versions[k]
+				}
+			}
+			return s, vs, nil
+		}
+	}
+	return nil, nil, ErrConflict
+}
+
+// Restore sets every Initiator in r named in s to its value in s, as if by 
+// SetValue. The values are set as if by Batch, in sorted order by name, so 
+// each Initiator propagates its change once, and Indicators bound to several 
+// of them recompute once. Names in s that are not registered in r are 
+// ignored, as are Initiators in r not named in s.
+func (r *Registry) Restore(s Snapshot) {
+	names,inits := r.sorted()
+	Batch(func() {
+		for k,i := range inits {
+			if v,ok := s[names[k]]; ok {
+				i.SetValue(v)
+			}
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package reactor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var r Registry
+	var a,b Trigger
+
+	r.Register("b", &b)
+	r.Register("a", &a)
+
+	if !reflect.DeepEqual(r.Names(), []string{"a", "b"}) {
+		t.Fatalf("Expected sorted names [a b]; got %v", r.Names())
+	}
+	if i,ok := r.Lookup("a"); !ok || i != &a {
+		t.Fatal("Expected to find a")
+	}
+
+	r.Unregister("a")
+	if _,ok := r.Lookup("a"); ok {
+		t.Fatal("Expected a to be unregistered")
+	}
+}
+
+func TestRegistrySnapshotRestore(t *testing.T) {
+	var r Registry
+	var width,height Trigger
+	var area Indicator
+	var propagated int
+
+	width.SetValue(2)
+	height.SetValue(3)
+	area.AddBinding(&width, func(v interface{}) interface{} {
+		return v.(int) * height.Value().(int)
+	})
+	area.AddBinding(&height, func(v interface{}) interface{} {
+		return width.Value().(int) * v.(int)
+	})
+	area.AddWriteCallback(func(prev, v interface{}) {
+		propagated += 1
+	})
+	r.Register("width", &width)
+	r.Register("height", &height)
+
+	s,err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(s, Snapshot{"width": 2, "height": 3}) {
+		t.Fatalf("Unexpected snapshot: %v", s)
+	}
+
+	width.SetValue(10)
+	height.SetValue(10)
+	propagated = 0
+
+	r.Restore(s)
+	if width.Value() != 2 || height.Value() != 3 || area.Value() != 6 {
+		t.Fatalf("Expected (2, 3, 6) after restore; got (%v, %v, %v)", width.Value(), height.Value(), area.Value())
+	}
+	if propagated != 1 {
+		t.Fatalf("Expected area to propagate once; propagated %d times", propagated)
+	}
+}
+
+func TestRegistrySnapshotConflict(t *testing.T) {
+	var r Registry
+	var a,b Trigger
+	var reads int
+
+	a.SetValue(0)
+	b.SetValue(0)
+	b.AddReadCallback(func(v interface{}) {
+		reads += 1
+		if reads == 1 {
+			a.SetValue(1)
+		}
+	})
+	r.Register("a", &a)
+	r.Register("b", &b)
+
+	s,err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s["a"] != 1 || reads != 2 {
+		t.Fatalf("Expected snapshot to be retaken after concurrent write; got %v after %d reads", s, reads)
+	}
+}
+
+func TestRegistrySnapshotNotTorn(t *testing.T) {
+	var r Registry
+	var a,b Trigger
+	var reads int
+
+	a.SetValue(1)
+	b.SetValue(1)
+	a.AddReadCallback(func(v interface{}) {
+		reads += 1
+		if reads > 1 {
+			return
+		}
+		err := Transaction(func(tx *Tx) error {
+			tx.Set(&a, 2)
+			tx.Set(&b, 2)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Unexpected transaction error: %v", err)
+		}
+	})
+	r.Register("a", &a)
+	r.Register("b", &b)
+
+	s,err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s["a"] != 2 || s["b"] != 2 {
+		t.Fatalf("Expected a consistent snapshot of (2, 2); got %v", s)
+	}
+}
+
+func TestRegistrySnapshotDelayedBinding(t *testing.T) {
+	var r Registry
+	var trigger Trigger
+	var ind Indicator
+
+	ind.AddDelayedBinding(&trigger, TrivialBinding)
+	trigger.SetValue(1)
+	r.Register("trigger", &trigger)
+	r.Register("ind", &ind)
+
+	s,err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s["ind"] != 1 {
+		t.Fatalf("Expected delayed binding to be evaluated; got %v", s)
+	}
+}
+
+func TestRegistrySnapshotVersions(t *testing.T) {
+	var r Registry
+	var a,b Trigger
+	r.Register("a", &a)
+	r.Register("b", &b)
+	a.SetValue(1)
+	a.SetValue(2)
+	b.SetValue(3)
+
+	s,versions,err := r.SnapshotVersions()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(s, Snapshot{"a": 2, "b": 3}) {
+		t.Fatalf("Expected a=2, b=3; got %v", s)
+	}
+	expected := map[string]uint64{"a": a.Version(), "b": b.Version()}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("Expected versions %v; got %v", expected, versions)
+	}
+}
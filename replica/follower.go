@@ -0,0 +1,223 @@
+package replica
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// Follower mirrors the Initiators served by a Leader. Replicas of individual
+// Initiators are obtained with Trigger, Slice and Dict, and are kept up to
+// date while Run is running. A Follower must be created with NewFollower.
+type Follower struct {
+	addr string
+	opts Options
+
+	lock sync.Mutex
+	entries map[string]replicated
+	synced bool
+	err error
+
+	// The versions in the last snapshot applied. Only used by Run.
+	versions map[string]uint64
+}
+
+// NewFollower returns a Follower of the Leader listening at addr, a TCP
+// address as accepted by net.Dial.
+func NewFollower(addr string, opts *Options) *Follower {
+	f := &Follower{addr: addr, entries: make(map[string]replicated)}
+	if opts != nil {
+		f.opts = *opts
+	}
+	return f
+}
+
+// returns the replica of name, creating it with create if there is not one.
+// Panics if the replica that exists was created by a different method.
+func (f *Follower) entry(name string, create func() replicated) replicated {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if e,ok := f.entries[name]; ok {
+		return e
+	}
+	e := create()
+	f.entries[name] = e
+	return e
+}
+
+// Trigger returns the replica of the Initiator the Leader serves as name.
+// Every call with the same name returns the same replica. Trigger panics if
+// name was already requested with Slice or Dict.
+func (f *Follower) Trigger(name string) *Trigger {
+	e := f.entry(name, func() replicated { return &Trigger{} })
+	t,ok := e.(*Trigger)
+	if !ok {
+		panic(fmt.Sprintf("replica: %s was requested as %T", name, e))
+	}
+	return t
+}
+
+// Slice returns the replica of the slice Trigger the Leader serves as name.
+// Every call with the same name returns the same replica. Slice panics if
+// name was already requested with Trigger or Dict.
+func (f *Follower) Slice(name string) *Slice {
+	e := f.entry(name, func() replicated { return &Slice{} })
+	s,ok := e.(*Slice)
+	if !ok {
+		panic(fmt.Sprintf("replica: %s was requested as %T", name, e))
+	}
+	return s
+}
+
+// Dict returns the replica of the dict Trigger the Leader serves as name.
+// Every call with the same name returns the same replica. Dict panics if name
+// was already requested with Trigger or Slice.
+func (f *Follower) Dict(name string) *Dict {
+	e := f.entry(name, func() replicated { return &Dict{} })
+	t,ok := e.(*Dict)
+	if !ok {
+		panic(fmt.Sprintf("replica: %s was requested as %T", name, e))
+	}
+	return t
+}
+
+// Synced reports whether f is connected to the Leader and has applied a
+// snapshot since connecting.
+func (f *Follower) Synced() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.synced
+}
+
+// Err returns the error that ended the most recent connection to the Leader,
+// or nil if there has not been one.
+func (f *Follower) Err() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.err
+}
+
+// Run connects to the Leader and applies its snapshot and events to the
+// replicas of f, until ctx is cancelled, at which point it returns the error
+// from ctx. If the connection fails, or an event cannot be applied, Run waits
+// for Options.RetryInterval and reconnects, resynchronizing from a new
+// snapshot. Run must not be called more than once at a time.
+func (f *Follower) Run(ctx context.Context) error {
+	interval := f.opts.RetryInterval
+	if interval <= 0 {
+		interval = DefaultRetryInterval
+	}
+
+	for {
+		err := f.session(ctx)
+
+		f.lock.Lock()
+			f.synced = false
+			if ctx.Err() == nil {
+				f.err = err
+			}
+		f.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// connects to the Leader and applies messages until the connection fails.
+func (f *Follower) session(ctx context.Context) error {
+	var d net.Dialer
+	conn,err := d.DialContext(ctx, "tcp", f.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		data,err := r.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		if err := f.apply(&m); err != nil {
+			return err
+		}
+	}
+}
+
+// applies m to the replica it names, or to every replica if m is a snapshot.
+// Events included in the last snapshot are skipped.
+func (f *Follower) apply(m *message) error {
+	if m.Type == msgSnapshot {
+		f.versions = m.Versions
+		return f.restore(m.Values)
+	}
+	if v,ok := f.versions[m.Name]; ok && m.Version != 0 && m.Version <= v {
+		// Already included in the snapshot.
+		return nil
+	}
+
+	f.lock.Lock()
+		e,ok := f.entries[m.Name]
+	f.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	if err := e.apply(m, &f.opts); err != nil {
+		return fmt.Errorf("replica: applying event %d to %s: %v", m.Seq, m.Name, err)
+	}
+	return nil
+}
+
+// restores every replica named in values, as if by reactor.Batch.
+func (f *Follower) restore(values map[string]json.RawMessage) error {
+	f.lock.Lock()
+		entries := make(map[string]replicated, len(f.entries))
+		for name,e := range f.entries {
+			entries[name] = e
+		}
+	f.lock.Unlock()
+
+	var err error
+	reactor.Batch(func() {
+		for name,raw := range values {
+			e,ok := entries[name]
+			if !ok {
+				continue
+			}
+			if rerr := e.restore(raw, &f.opts); rerr != nil && err == nil {
+				err = fmt.Errorf("replica: restoring %s: %v", name, rerr)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+		f.synced = true
+	f.lock.Unlock()
+	return nil
+}
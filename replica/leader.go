@@ -0,0 +1,295 @@
+package replica
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// queueSize is the number of messages queued for each follower. A follower
+// that falls further behind than this is disconnected, and resynchronizes
+// when it reconnects.
+const queueSize = 256
+
+// Interfaces for the callbacks used to observe events.
+type writeObservable interface {
+	AddWriteCallback(reactor.WriteCallback)
+}
+
+type indexObservable interface {
+	AddIndexWriteCallback(reactor.WriteCallback)
+}
+
+type keyObservable interface {
+	AddKeyWriteCallback(reactor.WriteCallback)
+}
+
+// subscriber is a connected follower.
+type subscriber struct {
+	conn net.Conn
+	queue chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+// Leader serves the Initiators in a Registry to Followers. A Leader must be
+// created with NewLeader.
+type Leader struct {
+	registry *reactor.Registry
+
+	lock sync.Mutex
+	seq uint64
+	subs map[*subscriber]struct{}
+	listeners []net.Listener
+	closed bool
+	err error
+}
+
+// NewLeader returns a Leader that serves the Initiators in reg, using the
+// names they are registered with. Events are observed with write callbacks,
+// which are added to every Initiator in reg when NewLeader is called, so
+// Initiators registered later are only included in snapshots.
+//
+// Writes to the same Initiator from several goroutines at once must be
+// synchronized by the caller, or their events may be sent in a different
+// order than they were applied.
+func NewLeader(reg *reactor.Registry) *Leader {
+	l := &Leader{registry: reg, subs: make(map[*subscriber]struct{})}
+	for _,name := range reg.Names() {
+		i,ok := reg.Lookup(name)
+		if ok {
+			l.observe(name, i)
+		}
+	}
+	return l
+}
+
+// adds the callbacks that publish the events of i.
+func (l *Leader) observe(name string, i reactor.Initiator) {
+	version := func() uint64 {
+		if v,ok := i.(reactor.Versioned); ok {
+			return v.Version()
+		}
+		return 0
+	}
+	if x,ok := i.(writeObservable); ok {
+		x.AddWriteCallback(func(prev, v interface{}) {
+			l.publish(message{Type: msgWrite, Name: name, Version: version()}, v)
+		})
+	}
+	if x,ok := i.(indexObservable); ok {
+		x.AddIndexWriteCallback(func(prev, v interface{}) {
+			p,n := prev.(slice.Index), v.(slice.Index)
+			switch {
+			case p.Key == -1:
+				l.publish(message{Type: msgIndex, Name: name, Version: version(), Op: opAppend, Index: n.Key}, n.Value)
+			case n.Key == -1:
+				l.publish(message{Type: msgIndex, Name: name, Version: version(), Op: opPop, Index: p.Key}, nil)
+			default:
+				l.publish(message{Type: msgIndex, Name: name, Version: version(), Op: opSet, Index: n.Key}, n.Value)
+			}
+		})
+	}
+	if x,ok := i.(keyObservable); ok {
+		x.AddKeyWriteCallback(func(prev, v interface{}) {
+			n := v.(dict.Pair)
+			key,err := dict.EncodeKey(n.Key)
+			if err != nil {
+				l.fail(err)
+				return
+			}
+			if n.Value == nil {
+				l.publish(message{Type: msgKey, Name: name, Version: version(), Op: opDelete, Key: key}, nil)
+				return
+			}
+			l.publish(message{Type: msgKey, Name: name, Version: version(), Op: opSet, Key: key}, n.Value)
+		})
+	}
+}
+
+func (l *Leader) fail(err error) {
+	l.lock.Lock()
+	if l.err == nil {
+		l.err = err
+	}
+	l.lock.Unlock()
+}
+
+// sends m, with v as its value, to every follower. Followers whose queues are
+// full are disconnected.
+func (l *Leader) publish(m message, v interface{}) {
+	if m.Op != opPop && m.Op != opDelete {
+		raw,err := encode(v)
+		if err != nil {
+			l.fail(err)
+			return
+		}
+		m.Value = raw
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.seq++
+	m.Seq = l.seq
+	data,err := json.Marshal(m)
+	if err != nil {
+		if l.err == nil {
+			l.err = err
+		}
+		return
+	}
+	data = append(data, '\n')
+
+	for s := range l.subs {
+		select {
+		case s.queue <- data:
+		default:
+			delete(l.subs, s)
+			s.close()
+		}
+	}
+}
+
+// Serve accepts followers on ln until ln fails or the Leader is closed. Serve
+// always returns a non-nil error, except after Close, when it returns nil.
+// Serve may be called with several listeners at once.
+func (l *Leader) Serve(ln net.Listener) error {
+	l.lock.Lock()
+	if l.closed {
+		l.lock.Unlock()
+		ln.Close()
+		return nil
+	}
+	l.listeners = append(l.listeners, ln)
+	l.lock.Unlock()
+
+	for {
+		conn,err := ln.Accept()
+		if err != nil {
+			l.lock.Lock()
+				closed := l.closed
+			l.lock.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go l.serve(conn)
+	}
+}
+
+// sends a snapshot to the follower on conn, followed by every event queued
+// for it. The follower is subscribed before the snapshot is taken, so no
+// event is missed, although some may already be included in the snapshot.
+// The follower skips those, by comparing their versions to the versions in
+// the snapshot.
+func (l *Leader) serve(conn net.Conn) {
+	s := &subscriber{conn: conn, queue: make(chan []byte, queueSize), done: make(chan struct{})}
+	defer s.close()
+
+	// Followers never send anything, so a read only returns once the
+	// connection is closed by either side.
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		s.close()
+	}()
+
+	l.lock.Lock()
+	if l.closed {
+		l.lock.Unlock()
+		return
+	}
+	l.subs[s] = struct{}{}
+	seq := l.seq
+	l.lock.Unlock()
+
+	defer func() {
+		l.lock.Lock()
+			delete(l.subs, s)
+		l.lock.Unlock()
+	}()
+
+	snap,versions,err := l.registry.SnapshotVersions()
+	if err != nil {
+		return
+	}
+	m := message{Type: msgSnapshot, Seq: seq, Values: make(map[string]json.RawMessage, len(snap)), Versions: versions}
+	for name,v := range snap {
+		if m.Values[name],err = encode(v); err != nil {
+			l.fail(err)
+			return
+		}
+	}
+	data,err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+
+	w := bufio.NewWriter(conn)
+	if _,err := w.Write(append(data, '\n')); err != nil {
+		return
+	}
+	if err := w.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case data := <-s.queue:
+			if _,err := w.Write(data); err != nil {
+				return
+			}
+			if len(s.queue) == 0 {
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close stops every call to Serve and disconnects every follower.
+func (l *Leader) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	var first error
+	for _,ln := range l.listeners {
+		if err := ln.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	for s := range l.subs {
+		s.close()
+		delete(l.subs, s)
+	}
+	return first
+}
+
+// Err returns the first error encountered encoding an event, or nil if there
+// has not been one.
+func (l *Leader) Err() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.err
+}
@@ -0,0 +1,391 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+// Trigger is a read-only replica of an Initiator served by a Leader. Its
+// value is only changed by the Follower it was created by, which runs its
+// callbacks and bindings as for reactor.Trigger. Until the Follower has
+// received a snapshot, its value is nil.
+type Trigger struct {
+	t reactor.Trigger
+}
+
+// Value returns the value of t, as described for reactor.Trigger.Value.
+func (t *Trigger) Value() interface{} {
+	return t.t.Value()
+}
+
+// SetValue panics with ErrReadOnly, since only the leader can change the
+// value of t.
+func (t *Trigger) SetValue(interface{}) {
+	panic(ErrReadOnly)
+}
+
+// Version returns the version of t, which increases whenever the Follower
+// changes its value.
+func (t *Trigger) Version() uint64 {
+	return t.t.Version()
+}
+
+// AddBinder adds a Binder to be set when the value of t changes, as described
+// for reactor.Trigger.AddBinder.
+func (t *Trigger) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	t.t.AddBinder(b, f, concurrent)
+}
+
+// Watch returns a channel that receives a Change for every write to t, as
+// described for reactor.Trigger.Watch.
+func (t *Trigger) Watch(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return t.t.Watch(ctx, mode)
+}
+
+// AddReadCallback adds a callback that will be run when t is read using Value.
+func (t *Trigger) AddReadCallback(r reactor.ReadCallback) {
+	t.t.AddReadCallback(r)
+}
+
+// AddWriteCallback adds a callback that will be run when the Follower changes
+// the value of t.
+func (t *Trigger) AddWriteCallback(w reactor.WriteCallback) {
+	t.t.AddWriteCallback(w)
+}
+
+func (t *Trigger) restore(raw json.RawMessage, opts *Options) error {
+	v,err := opts.decode(raw)
+	if err != nil {
+		return err
+	}
+	t.t.SetValue(v)
+	return nil
+}
+
+func (t *Trigger) apply(m *message, opts *Options) error {
+	if m.Type != msgWrite {
+		return nil
+	}
+	return t.restore(m.Value, opts)
+}
+
+// Slice is a read-only replica of a slice Trigger served by a Leader. Its
+// value is only changed by the Follower it was created by, which runs its
+// callbacks and bindings, including index-level ones, as for slice.Trigger.
+// Until the Follower has received a snapshot, it is empty.
+type Slice struct {
+	s slice.Trigger
+}
+
+// Value returns the value of s, as described for slice.Trigger.Value.
+func (s *Slice) Value() interface{} {
+	return s.s.Value()
+}
+
+// SetValue panics with ErrReadOnly, since only the leader can change the
+// value of s.
+func (s *Slice) SetValue(interface{}) {
+	panic(ErrReadOnly)
+}
+
+// Version returns the version of s, which increases whenever the Follower
+// changes its value.
+func (s *Slice) Version() uint64 {
+	return s.s.Version()
+}
+
+// At returns the value at index, as described for slice.Trigger.At.
+func (s *Slice) At(index int) (interface{}, error) {
+	return s.s.At(index)
+}
+
+// Slice returns the values in [from, to), as described for
+// slice.Trigger.Slice.
+func (s *Slice) Slice(from, to int) ([]interface{}, error) {
+	return s.s.Slice(from, to)
+}
+
+// Size returns the length of s.
+func (s *Slice) Size() int {
+	return s.s.Size()
+}
+
+// AddBinder adds a Binder to be set when the value of s changes, as described
+// for slice.Trigger.AddBinder.
+func (s *Slice) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	s.s.AddBinder(b, f, concurrent)
+}
+
+// AddIndexBinder adds a Binder to be set when the value at index changes, as
+// described for slice.Trigger.AddIndexBinder.
+func (s *Slice) AddIndexBinder(index int, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	s.s.AddIndexBinder(index, b, f, concurrent)
+}
+
+// AddRangeBinder adds a Binder to be set when a value in [from, to) changes,
+// as described for slice.Trigger.AddRangeBinder.
+func (s *Slice) AddRangeBinder(from, to int, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	s.s.AddRangeBinder(from, to, b, f, concurrent)
+}
+
+// Watch returns a channel that receives a Change for every write to s, as
+// described for slice.Trigger.Watch.
+func (s *Slice) Watch(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return s.s.Watch(ctx, mode)
+}
+
+// WatchIndex returns a channel that receives a Change for every index-level
+// write to s, as described for slice.Trigger.WatchIndex.
+func (s *Slice) WatchIndex(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return s.s.WatchIndex(ctx, mode)
+}
+
+// AddReadCallback adds a callback that will be run when s is read using Value.
+func (s *Slice) AddReadCallback(r reactor.ReadCallback) {
+	s.s.AddReadCallback(r)
+}
+
+// AddWriteCallback adds a callback that will be run when the Follower sets
+// the whole value of s.
+func (s *Slice) AddWriteCallback(w reactor.WriteCallback) {
+	s.s.AddWriteCallback(w)
+}
+
+// AddIndexReadCallback adds a callback that will be run when an element of s
+// is read using At.
+func (s *Slice) AddIndexReadCallback(r reactor.ReadCallback) {
+	s.s.AddIndexReadCallback(r)
+}
+
+// AddIndexWriteCallback adds a callback that will be run when the Follower
+// changes, appends or removes a single element of s.
+func (s *Slice) AddIndexWriteCallback(w reactor.WriteCallback) {
+	s.s.AddIndexWriteCallback(w)
+}
+
+func (s *Slice) restore(raw json.RawMessage, opts *Options) error {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err != nil {
+		return err
+	}
+	v := make([]interface{}, len(elems))
+	for n,e := range elems {
+		var err error
+		if v[n],err = opts.decode(e); err != nil {
+			return err
+		}
+	}
+	s.s.SetValue(v)
+	return nil
+}
+
+// applies m to s. Index messages are applied only if they have not been
+// already, which is determined by comparing the size of s to their index.
+func (s *Slice) apply(m *message, opts *Options) error {
+	switch m.Type {
+	case msgWrite:
+		return s.restore(m.Value, opts)
+	case msgIndex:
+	default:
+		return nil
+	}
+
+	var v interface{}
+	if m.Op != opPop {
+		var err error
+		if v,err = opts.decode(m.Value); err != nil {
+			return err
+		}
+	}
+
+	size := s.s.Size()
+	switch m.Op {
+	case opSet:
+		if m.Index < size {
+			return s.s.SetAt(m.Index, v)
+		}
+	case opAppend:
+		if m.Index == size {
+			s.s.Append(v)
+			return nil
+		}
+		if m.Index < size {
+			return s.s.SetAt(m.Index, v)
+		}
+	case opPop:
+		if m.Index == size-1 {
+			_,err := s.s.Pop()
+			return err
+		}
+		if m.Index >= size {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", m.Op)
+	}
+	return fmt.Errorf("%s at index %d does not match size %d", m.Op, m.Index, size)
+}
+
+// Dict is a read-only replica of a dict Trigger served by a Leader. Its value
+// is only changed by the Follower it was created by, which runs its callbacks
+// and bindings, including key-level ones, as for dict.Trigger. Until the
+// Follower has received a snapshot, it is empty.
+type Dict struct {
+	t dict.Trigger
+}
+
+// Value returns the value of t, as described for dict.Trigger.Value.
+func (t *Dict) Value() interface{} {
+	return t.t.Value()
+}
+
+// SetValue panics with ErrReadOnly, since only the leader can change the
+// value of t.
+func (t *Dict) SetValue(interface{}) {
+	panic(ErrReadOnly)
+}
+
+// Version returns the version of t, which increases whenever the Follower
+// changes its value.
+func (t *Dict) Version() uint64 {
+	return t.t.Version()
+}
+
+// Get returns the value of key, as described for dict.Trigger.Get.
+func (t *Dict) Get(key interface{}) interface{} {
+	return t.t.Get(key)
+}
+
+// GetCheck returns the value of key and whether it exists, as described for
+// dict.Trigger.GetCheck.
+func (t *Dict) GetCheck(key interface{}) (interface{}, bool) {
+	return t.t.GetCheck(key)
+}
+
+// Keys returns the keys of t, in no particular order.
+func (t *Dict) Keys() []interface{} {
+	return t.t.Keys()
+}
+
+// Values returns the values of t, in no particular order.
+func (t *Dict) Values() []interface{} {
+	return t.t.Values()
+}
+
+// Size returns the number of keys in t.
+func (t *Dict) Size() int {
+	return t.t.Size()
+}
+
+// AddBinder adds a Binder to be set when the value of t changes, as described
+// for dict.Trigger.AddBinder.
+func (t *Dict) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	t.t.AddBinder(b, f, concurrent)
+}
+
+// AddKeyBinder adds a Binder to be set when the value of key changes, as
+// described for dict.Trigger.AddKeyBinder.
+func (t *Dict) AddKeyBinder(key interface{}, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	t.t.AddKeyBinder(key, b, f, concurrent)
+}
+
+// AddKeysBinder adds a Binder to be set when the value of any of keys
+// changes, as described for dict.Trigger.AddKeysBinder.
+func (t *Dict) AddKeysBinder(keys []interface{}, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	t.t.AddKeysBinder(keys, b, f, concurrent)
+}
+
+// Watch returns a channel that receives a Change for every write to t, as
+// described for dict.Trigger.Watch.
+func (t *Dict) Watch(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return t.t.Watch(ctx, mode)
+}
+
+// WatchKey returns a channel that receives a Change for every key-level write
+// to t, as described for dict.Trigger.WatchKey.
+func (t *Dict) WatchKey(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return t.t.WatchKey(ctx, mode)
+}
+
+// AddReadCallback adds a callback that will be run when t is read using Value.
+func (t *Dict) AddReadCallback(r reactor.ReadCallback) {
+	t.t.AddReadCallback(r)
+}
+
+// AddWriteCallback adds a callback that will be run when the Follower sets
+// the whole value of t.
+func (t *Dict) AddWriteCallback(w reactor.WriteCallback) {
+	t.t.AddWriteCallback(w)
+}
+
+// AddKeyReadCallback adds a callback that will be run when a key of t is read
+// using Get or GetCheck.
+func (t *Dict) AddKeyReadCallback(r reactor.ReadCallback) {
+	t.t.AddKeyReadCallback(r)
+}
+
+// AddKeyWriteCallback adds a callback that will be run when the Follower sets
+// or deletes a single key of t.
+func (t *Dict) AddKeyWriteCallback(w reactor.WriteCallback) {
+	t.t.AddKeyWriteCallback(w)
+}
+
+func (t *Dict) restore(raw json.RawMessage, opts *Options) error {
+	var pairs map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &pairs); err != nil {
+		return err
+	}
+	m := make(map[interface{}]interface{}, len(pairs))
+	for k,e := range pairs {
+		key,err := opts.decodeKey(k)
+		if err != nil {
+			return err
+		}
+		if m[key],err = opts.decode(e); err != nil {
+			return err
+		}
+	}
+	t.t.SetValue(m)
+	return nil
+}
+
+// applies m to t. Setting and deleting keys is idempotent, so key messages
+// are always applied.
+func (t *Dict) apply(m *message, opts *Options) error {
+	switch m.Type {
+	case msgWrite:
+		return t.restore(m.Value, opts)
+	case msgKey:
+	default:
+		return nil
+	}
+
+	key,err := opts.decodeKey(m.Key)
+	if err != nil {
+		return err
+	}
+	switch m.Op {
+	case opSet:
+		v,err := opts.decode(m.Value)
+		if err != nil {
+			return err
+		}
+		t.t.Set(key, v)
+	case opDelete:
+		// Keys is used rather than GetCheck, so no read callbacks are run.
+		for _,k := range t.t.Keys() {
+			if k == key {
+				t.t.Delete(key)
+				break
+			}
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", m.Op)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package replica mirrors the Initiators in a reactor.Registry from a leader
+// process to any number of follower processes over TCP. A Leader sends each
+// follower that connects a snapshot of every value, followed by each write,
+// index and key event as it happens. A Follower applies them to read-only
+// Initiators, which run their callbacks and bindings locally, like any other
+// Initiator. If a follower is disconnected, it reconnects and resynchronizes
+// from a new snapshot.
+//
+// The protocol is a stream of JSON lines from the leader to the follower.
+// Values must therefore be encodable with encoding/json, and maps used by
+// dict Triggers are encoded with string keys, as described for
+// dict.EncodeKey. Each event carries the version of its Initiator after the
+// write, and each snapshot the version of every value in it, so a follower
+// skips the events already included in the snapshot it was sent. Events of
+// Initiators that are not reactor.Versioned cannot be skipped; writes of
+// whole values are applied again, and index and key events are idempotent,
+// so applying them again leaves the same value.
+package replica
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+)
+
+// ErrReadOnly is the value with which the write methods of replicated
+// Initiators panic.
+var ErrReadOnly = errors.New("replica: replicated Initiators are read-only")
+
+// The types of message sent by a Leader.
+const (
+	msgSnapshot = "snapshot"
+	msgWrite = "write"
+	msgIndex = "index"
+	msgKey = "key"
+)
+
+// The operations of index and key messages.
+const (
+	opSet = "set"
+	opAppend = "append"
+	opPop = "pop"
+	opDelete = "delete"
+)
+
+// message is a single line of the protocol. For index messages, Index is the
+// index written, appended or removed, so that replaying the message is a
+// no-op if it has already been applied. Version is the version of the
+// Initiator written, or 0 if it is not reactor.Versioned, and Versions the
+// versions of the values in a snapshot.
+type message struct {
+	Type string `json:"type"`
+	Seq uint64 `json:"seq"`
+	Name string `json:"name,omitempty"`
+	Version uint64 `json:"version,omitempty"`
+	Op string `json:"op,omitempty"`
+	Index int `json:"index,omitempty"`
+	Key string `json:"key,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Values map[string]json.RawMessage `json:"values,omitempty"`
+	Versions map[string]uint64 `json:"versions,omitempty"`
+}
+
+// encodes v, encoding the keys of maps used by dict Triggers.
+func encode(v interface{}) (json.RawMessage, error) {
+	if m,ok := v.(map[interface{}]interface{}); ok {
+		n,err := dict.EncodeMap(m)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(n)
+	}
+	return json.Marshal(v)
+}
+
+// DefaultRetryInterval is the interval used by a Follower between attempts
+// to connect when Options.RetryInterval is not set.
+const DefaultRetryInterval = time.Second
+
+// Options configures a Follower. A nil *Options is equivalent to the zero
+// value.
+type Options struct {
+	// Decode converts a value received from the leader back into the value
+	// that was written. For slices, it is called once per element, and for
+	// dicts, once per value. If Decode is nil, values are decoded as they
+	// would be into an interface{}.
+	Decode func(json.RawMessage) (interface{}, error)
+
+	// DecodeKey converts the keys of dicts, which are sent as strings. If
+	// DecodeKey is nil, keys are left as strings.
+	DecodeKey dict.KeyDecoder
+
+	// RetryInterval is the time waited between attempts to connect to the
+	// leader. If it is not positive, DefaultRetryInterval is used.
+	RetryInterval time.Duration
+}
+
+func (o *Options) decode(raw json.RawMessage) (interface{}, error) {
+	if o.Decode != nil {
+		return o.Decode(raw)
+	}
+	var v interface{}
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+func (o *Options) decodeKey(k string) (interface{}, error) {
+	if o.DecodeKey != nil {
+		return o.DecodeKey(k)
+	}
+	return k, nil
+}
+
+// replicated is implemented by the read-only Initiators of a Follower.
+type replicated interface {
+	reactor.Initiator
+	restore(raw json.RawMessage, opts *Options) error
+	apply(m *message, opts *Options) error
+}
@@ -0,0 +1,229 @@
+package replica
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KellenWatt/reactor"
+	"github.com/KellenWatt/reactor/dict"
+	"github.com/KellenWatt/reactor/slice"
+)
+
+type leaderFixture struct {
+	level reactor.Trigger
+	queue slice.Trigger
+	flags dict.Trigger
+	leader *Leader
+	addr string
+}
+
+func startLeader(t *testing.T) *leaderFixture {
+	f := &leaderFixture{}
+	var reg reactor.Registry
+	reg.Register("level", &f.level)
+	reg.Register("queue", &f.queue)
+	reg.Register("flags", &f.flags)
+	f.level.SetValue(1.0)
+	f.queue.SetValue([]interface{}{"a"})
+	f.flags.Set(1, true)
+
+	ln,err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.addr = ln.Addr().String()
+	f.leader = NewLeader(&reg)
+	go f.leader.Serve(ln)
+	return f
+}
+
+// waits for the value of i to satisfy pred.
+func waitFor(t *testing.T, i reactor.Initiator, pred func(interface{}) bool) {
+	ctx,cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _,err := reactor.WaitFor(ctx, i, pred); err != nil {
+		t.Fatalf("Timed out waiting; value is %v", i.Value())
+	}
+}
+
+func intKeys(k string) (interface{}, error) {
+	return strconv.Atoi(k)
+}
+
+func TestReplication(t *testing.T) {
+	l := startLeader(t)
+	defer l.leader.Close()
+
+	follower := NewFollower(l.addr, &Options{DecodeKey: intKeys, RetryInterval: 10*time.Millisecond})
+	level := follower.Trigger("level")
+	queue := follower.Slice("queue")
+	flags := follower.Dict("flags")
+
+	var doubled reactor.Indicator
+	doubled.AddBinding(level, func(v interface{}) interface{} {
+		if v == nil {
+			return nil
+		}
+		return v.(float64) * 2
+	})
+
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go follower.Run(ctx)
+
+	waitFor(t, level, func(v interface{}) bool { return v == 1.0 })
+	if doubled.Value() != 2.0 {
+		t.Fatalf("Expected local binding to run; got %v", doubled.Value())
+	}
+
+	l.level.SetValue(5.0)
+	l.queue.Append("b")
+	l.queue.SetAt(0, "z")
+	l.queue.Append("c")
+	l.queue.Pop()
+	l.flags.Set(2, false)
+	l.flags.Delete(1)
+
+	waitFor(t, level, func(v interface{}) bool { return v == 5.0 })
+	waitFor(t, queue, func(v interface{}) bool {
+		return reflect.DeepEqual(v, []interface{}{"z", "b"})
+	})
+	waitFor(t, flags, func(v interface{}) bool {
+		return reflect.DeepEqual(v, map[interface{}]interface{}{2: false})
+	})
+	if doubled.Value() != 10.0 {
+		t.Fatalf("Expected 10; got %v", doubled.Value())
+	}
+}
+
+func TestReplicationResync(t *testing.T) {
+	l := startLeader(t)
+
+	follower := NewFollower(l.addr, &Options{RetryInterval: 10*time.Millisecond})
+	level := follower.Trigger("level")
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go follower.Run(ctx)
+	waitFor(t, level, func(v interface{}) bool { return v == 1.0 })
+
+	// Restart the leader on the same address, with a new value set while the
+	// follower is disconnected.
+	l.leader.Close()
+	l.level.SetValue(7.0)
+	ln,err := net.Listen("tcp", l.addr)
+	if err != nil {
+		t.Skipf("Could not listen on %s again: %v", l.addr, err)
+	}
+	var reg reactor.Registry
+	reg.Register("level", &l.level)
+	leader := NewLeader(&reg)
+	defer leader.Close()
+	go leader.Serve(ln)
+
+	waitFor(t, level, func(v interface{}) bool { return v == 7.0 })
+	if !follower.Synced() {
+		t.Fatal("Expected follower to be synced")
+	}
+}
+
+func TestReplicationWriteWhileConnecting(t *testing.T) {
+	var a,b reactor.Trigger
+	var reg reactor.Registry
+	reg.Register("a", &a)
+	reg.Register("b", &b)
+	a.SetValue(1.0)
+	b.SetValue(0.0)
+
+	// The first snapshot reads a, and then writes it twice while reading b,
+	// so it is retaken with both writes included, and their events are
+	// queued after it.
+	var once sync.Once
+	b.AddReadCallback(func(interface{}) {
+		once.Do(func() {
+			a.SetValue(2.0)
+			a.SetValue(3.0)
+		})
+	})
+
+	ln,err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	leader := NewLeader(&reg)
+	defer leader.Close()
+	go leader.Serve(ln)
+
+	follower := NewFollower(ln.Addr().String(), &Options{RetryInterval: 10*time.Millisecond})
+	replica := follower.Trigger("a")
+	var lock sync.Mutex
+	var written []interface{}
+	replica.AddWriteCallback(func(prev, v interface{}) {
+		lock.Lock()
+			written = append(written, v)
+		lock.Unlock()
+	})
+
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go follower.Run(ctx)
+	waitFor(t, replica, func(v interface{}) bool { return v == 3.0 })
+
+	a.SetValue(4.0)
+	waitFor(t, replica, func(v interface{}) bool { return v == 4.0 })
+	lock.Lock()
+	defer lock.Unlock()
+	if !reflect.DeepEqual(written, []interface{}{3.0, 4.0}) {
+		t.Fatalf("Expected events in the snapshot to be skipped; got writes %v", written)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	follower := NewFollower("127.0.0.1:0", nil)
+	for _,i := range []reactor.Initiator{follower.Trigger("a"), follower.Slice("b"), follower.Dict("c")} {
+		func() {
+			defer func() {
+				if recover() != ErrReadOnly {
+					t.Fatalf("Expected %T.SetValue to panic with ErrReadOnly", i)
+				}
+			}()
+			i.SetValue(nil)
+		}()
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic requesting a with a different type")
+		}
+	}()
+	follower.Slice("a")
+}
+
+func TestSliceApplyIdempotent(t *testing.T) {
+	var s Slice
+	opts := &Options{}
+	s.s.SetValue([]interface{}{"a", "b"})
+
+	// Already applied: the append of b and the pop of c.
+	for _,m := range []message{
+		{Type: msgIndex, Op: opAppend, Index: 1, Value: []byte(`"b"`)},
+		{Type: msgIndex, Op: opPop, Index: 2},
+		{Type: msgIndex, Op: opAppend, Index: 2, Value: []byte(`"c"`)},
+	} {
+		if err := s.apply(&m, opts); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(s.Value(), []interface{}{"a", "b", "c"}) {
+		t.Fatalf("Expected [a b c]; got %v", s.Value())
+	}
+
+	m := message{Type: msgIndex, Op: opAppend, Index: 5, Value: []byte(`"x"`)}
+	if err := s.apply(&m, opts); err == nil {
+		t.Fatal("Expected error for an append beyond the end")
+	}
+}
@@ -0,0 +1,148 @@
+package reactor
+
+import (
+	"sort"
+	"time"
+)
+
+// Sample is a value held by an Initiator, along with the time it was set.
+type Sample struct {
+	Time time.Time
+	Value interface{}
+}
+
+// samples is a bounded record of the values set on an Initiator. The zero
+// value records nothing. samples is not safe for concurrent use; it is
+// protected by the lock of its owner.
+type samples struct {
+	enabled bool
+	max int
+	age time.Duration
+	list []Sample
+}
+
+// configures s to keep at most max samples, and samples no older than age,
+// starting with v as the current value. If both are zero, s is disabled and
+// its samples are discarded.
+func (s *samples) keep(max int, age time.Duration, v interface{}) {
+	if max <= 0 && age <= 0 {
+		*s = samples{}
+		return
+	}
+	wasEnabled := s.enabled
+	s.enabled, s.max, s.age = true, max, age
+	if !wasEnabled {
+		s.list = []Sample{{time.Now(), v}}
+	}
+	s.prune(time.Now())
+}
+
+// records v as the value set at the current time, if s is enabled.
+func (s *samples) add(v interface{}) {
+	if !s.enabled {
+		return
+	}
+	now := time.Now()
+	s.list = append(s.list, Sample{now, v})
+	s.prune(now)
+}
+
+// discards samples beyond the limits of s. The newest sample older than age
+// is kept, since it is the value that was current at that point.
+func (s *samples) prune(now time.Time) {
+	drop := 0
+	if s.max > 0 && len(s.list) > s.max {
+		drop = len(s.list) - s.max
+	}
+	if s.age > 0 {
+		cutoff := now.Add(-s.age)
+		for drop < len(s.list)-1 && !s.list[drop+1].Time.After(cutoff) {
+			drop++
+		}
+	}
+	if drop > 0 {
+		// Reslicing rather than copying keeps each write O(1), amortized:
+		// append moves the kept samples to a new array only once the old one
+		// is full. The dropped samples are cleared so their values can be
+		// collected in the meantime.
+		for n := range s.list[:drop] {
+			s.list[n] = Sample{}
+		}
+		s.list = s.list[drop:]
+	}
+}
+
+// returns a copy of the samples in s, pruned as of now.
+func (s *samples) history() []Sample {
+	s.prune(time.Now())
+	h := make([]Sample, len(s.list))
+	copy(h, s.list)
+	return h
+}
+
+// returns the value that was current at t, and whether it is known.
+func (s *samples) at(t time.Time) (interface{}, bool) {
+	s.prune(time.Now())
+	n := sort.Search(len(s.list), func(i int) bool {
+		return s.list[i].Time.After(t)
+	})
+	if n == 0 {
+		return nil, false
+	}
+	return s.list[n-1].Value, true
+}
+
+// KeepHistory configures t to keep a history of its values, with the time 
+// each was set. At most max values are kept, and values older than age are 
+// discarded, except for the value that was current age ago. If either is 
+// zero, it does not limit the history. If both are zero, no history is kept, 
+// and any existing history is discarded, which is the default.
+//
+// When history is first enabled, the current value of t is recorded as of 
+// the time KeepHistory is called.
+func (t *Trigger) KeepHistory(max int, age time.Duration) {
+	t.Lock.Lock()
+		t.samples.keep(max, age, t.value)
+	t.Lock.Unlock()
+}
+
+// History returns the values kept by t, as configured by KeepHistory, from 
+// oldest to newest. No read callbacks are run.
+func (t *Trigger) History() []Sample {
+	t.Lock.Lock()
+	defer t.Lock.Unlock()
+	return t.samples.history()
+}
+
+// ValueAt returns the value t held at time at, and whether it is known. The 
+// value is only known if at is no earlier than the oldest value kept by t, as 
+// configured by KeepHistory. No read callbacks are run.
+func (t *Trigger) ValueAt(at time.Time) (interface{}, bool) {
+	t.Lock.Lock()
+	defer t.Lock.Unlock()
+	return t.samples.at(at)
+}
+
+// KeepHistory configures n to keep a history of its values, as described for 
+// Trigger.KeepHistory. Values set by bindings are kept like any other.
+func (n *Indicator) KeepHistory(max int, age time.Duration) {
+	n.Lock.Lock()
+		n.samples.keep(max, age, n.value)
+	n.Lock.Unlock()
+}
+
+// History returns the values kept by n, as described for Trigger.History. 
+// Delayed bindings are not evaluated.
+func (n *Indicator) History() []Sample {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+	return n.samples.history()
+}
+
+// ValueAt returns the value n held at time at, and whether it is known, as 
+// described for Trigger.ValueAt. Delayed bindings are not evaluated.
+func (n *Indicator) ValueAt(at time.Time) (interface{}, bool) {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+	return n.samples.at(at)
+}
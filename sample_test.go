@@ -0,0 +1,110 @@
+package reactor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeepHistoryMax(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(0)
+	trigger.KeepHistory(3, 0)
+
+	for n:=1; n<=5; n++ {
+		trigger.SetValue(n)
+	}
+
+	h := trigger.History()
+	if len(h) != 3 || h[0].Value != 3 || h[2].Value != 5 {
+		t.Fatalf("Expected values 3 to 5; got %v", h)
+	}
+	for n:=1; n<len(h); n++ {
+		if h[n].Time.Before(h[n-1].Time) {
+			t.Fatalf("Expected samples in order; got %v", h)
+		}
+	}
+
+	trigger.KeepHistory(0, 0)
+	if len(trigger.History()) != 0 {
+		t.Fatalf("Expected history to be discarded; got %v", trigger.History())
+	}
+}
+
+func TestKeepHistoryMaxAllocs(t *testing.T) {
+	var s samples
+	var v interface{} = "value"
+	s.keep(1000, 0, v)
+	for n:=0; n<1000; n++ {
+		s.add(v)
+	}
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		s.add(v)
+	})
+	if allocs >= 0.5 {
+		t.Fatalf("Expected writes at capacity not to copy the history each time; got %v allocations per write", allocs)
+	}
+	if len(s.list) != 1000 || s.list[0].Value != v {
+		t.Fatalf("Expected 1000 samples; got %d", len(s.list))
+	}
+}
+
+func TestKeepHistoryAge(t *testing.T) {
+	var trigger Trigger
+	trigger.KeepHistory(0, 20*time.Millisecond)
+	trigger.SetValue("old")
+	time.Sleep(40*time.Millisecond)
+	trigger.SetValue("new")
+
+	h := trigger.History()
+	if len(h) != 2 || h[0].Value != "old" {
+		t.Fatalf("Expected the value current at the cutoff to be kept; got %v", h)
+	}
+	time.Sleep(40*time.Millisecond)
+	if h := trigger.History(); len(h) != 1 || h[0].Value != "new" {
+		t.Fatalf("Expected only the current value; got %v", h)
+	}
+}
+
+func TestValueAt(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	ind.AddBinding(&trigger, func(v interface{}) interface{} {
+		return v.(int) * 2
+	})
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+	ind.KeepHistory(10, 0)
+	start := time.Now()
+	time.Sleep(time.Millisecond)
+
+	trigger.SetValue(1)
+	mid := time.Now()
+	time.Sleep(time.Millisecond)
+	trigger.SetValue(2)
+
+	if _,ok := ind.ValueAt(before); ok {
+		t.Fatal("Expected value before history began to be unknown")
+	}
+	if v,ok := ind.ValueAt(start); !ok || v != nil {
+		t.Fatalf("Expected initial nil value; got %v, %v", v, ok)
+	}
+	if v,ok := ind.ValueAt(mid); !ok || v != 2 {
+		t.Fatalf("Expected 2; got %v, %v", v, ok)
+	}
+	if v,ok := ind.ValueAt(time.Now()); !ok || v != 4 {
+		t.Fatalf("Expected 4; got %v, %v", v, ok)
+	}
+}
+
+func TestKeepHistoryTransaction(t *testing.T) {
+	var trigger Trigger
+	trigger.KeepHistory(10, 0)
+	Transaction(func(tx *Tx) error {
+		tx.Set(&trigger, 1)
+		return nil
+	})
+	if h := trigger.History(); len(h) != 2 || h[1].Value != 1 {
+		t.Fatalf("Expected committed value in history; got %v", h)
+	}
+}
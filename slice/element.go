@@ -0,0 +1,209 @@
+package slice
+
+import (
+	"github.com/KellenWatt/reactor"
+)
+
+// indexBinding is a reactor.Binding that depends on a single index or a range
+// of indices of a Trigger, rather than the entire slice.
+type indexBinding struct {
+	from, to int
+	single bool
+	reactor.Binding
+}
+
+// resolves the bounds of i against a slice of length n. Negative indices are
+// counted from the end of the slice. The resulting bounds are always within
+// [0, n], and are empty if nothing in the slice is covered by i.
+func (i indexBinding) resolve(n int) (int, int) {
+	from := i.from
+	if from < 0 {
+		from += n
+	}
+	if i.single {
+		if from < 0 || from >= n {
+			return 0, 0
+		}
+		return from, from+1
+	}
+
+	to := i.to
+	if to < 0 {
+		to += n
+	}
+	from = clamp(from, n)
+	to = clamp(to, n)
+	if to < from {
+		to = from
+	}
+	return from, to
+}
+
+// value returns the value passed to the BindingFunc of i for the slice v.
+func (i indexBinding) value(v []interface{}) interface{} {
+	from,to := i.resolve(len(v))
+	if i.single {
+		if from == to {
+			return Index{-1, nil}
+		}
+		return Index{from, v[from]}
+	}
+	val := make([]interface{}, to-from)
+	copy(val, v[from:to])
+	return val
+}
+
+// affected reports whether a change to the indices [from, to) that changed
+// the length of the slice from prevLen to n requires i to be run.
+func (i indexBinding) affected(from, to, prevLen, n int) bool {
+	pFrom,pTo := i.resolve(prevLen)
+	nFrom,nTo := i.resolve(n)
+	if pFrom != nFrom || pTo != nTo {
+		return true
+	}
+	return nFrom < to && from < nTo
+}
+
+func clamp(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+// pendingBinding is an index binding waiting to be run, along with the value
+// that will be passed to it.
+type pendingBinding struct {
+	reactor.Binding
+	value interface{}
+}
+
+func runPending(probe *reactor.Probe, pending []pendingBinding) {
+	for _,p := range pending {
+		reactor.RunBinding(probe, p.Binding, p.value)
+	}
+}
+
+// Element is a reactor.Initiator representing a single index of a Trigger.
+// Binders bound to an Element are only updated when the value at that index
+// changes. A negative index is counted from the end of the slice, so an
+// Element for index -1 always represents the last element.
+//
+// Because the Trigger can grow and shrink, the index represented by an
+// Element may not always exist. In that case, Value returns Index{-1, nil}.
+type Element struct {
+	s *Trigger
+	index int
+}
+
+// Element returns an Element representing index of s.
+func (s *Trigger) Element(index int) *Element {
+	return &Element{s, index}
+}
+
+// Value returns the index represented by e and the value at that index, as
+// an Index struct. If the index does not currently exist in the Trigger,
+// Value returns Index{-1, nil}.
+//
+// If the index exists, any callbacks registered with AddIndexReadCallback
+// on the Trigger will be called, as if by At.
+func (e *Element) Value() interface{} {
+	e.s.Lock.Lock()
+		i := indexBinding{from: e.index, single: true}
+		from,to := i.resolve(len(e.s.value))
+	e.s.Lock.Unlock()
+	if from == to {
+		return Index{-1, nil}
+	}
+
+	v,err := e.s.At(from)
+	if err != nil {
+		return Index{-1, nil}
+	}
+	return Index{from, v}
+}
+
+// SetValue sets the value at the index represented by e to v, as if by SetAt.
+// If the index does not currently exist in the Trigger, SetValue panics with
+// an OutOfBoundsError.
+func (e *Element) SetValue(v interface{}) {
+	e.s.Lock.Lock()
+		i := indexBinding{from: e.index, single: true}
+		from,to := i.resolve(len(e.s.value))
+	e.s.Lock.Unlock()
+	if from == to {
+		panic(NewError(e.index))
+	}
+
+	if err := e.s.SetAt(from, v); err != nil {
+		panic(err)
+	}
+}
+
+// AddBinder adds a Binder to be executed when the value at the index
+// represented by e changes. This is equivalent to calling AddIndexBinder on
+// the underlying Trigger.
+func (e *Element) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	e.s.AddIndexBinder(e.index, b, f, concurrent)
+}
+
+// Span is a reactor.Initiator representing a range of indices of a Trigger.
+// Binders bound to a Span are only updated when a value within that range
+// changes. Negative bounds are counted from the end of the slice, and bounds
+// beyond either end of the slice are clamped to it.
+type Span struct {
+	s *Trigger
+	from, to int
+}
+
+// Range returns a Span representing the indices [from, to) of s.
+func (s *Trigger) Range(from, to int) *Span {
+	return &Span{s, from, to}
+}
+
+// Value returns a copy of the values in the range represented by p.
+//
+// Any callbacks registered with AddReadCallback on the Trigger will be called,
+// as if by Slice.
+func (p *Span) Value() interface{} {
+	p.s.Lock.Lock()
+		v := indexBinding{from: p.from, to: p.to}.value(p.s.value)
+	p.s.Lock.Unlock()
+
+	start := reactor.StartEvent(&p.s.Probe)
+	for _,c := range p.s.readCallbacks {
+		c(v)
+	}
+	reactor.EmitEvent(&p.s.Probe, reactor.Event{Kind: reactor.ReadEvent, Value: v}, start)
+
+	return v
+}
+
+// SetValue sets the values in the range represented by p to those in v, as
+// if by SetAt. v is tested to ensure that it is of type []interface{} and
+// the same length as the range. If it is not, SetValue panics.
+func (p *Span) SetValue(v interface{}) {
+	val := v.([]interface{})
+	p.s.Lock.Lock()
+		from,to := indexBinding{from: p.from, to: p.to}.resolve(len(p.s.value))
+	p.s.Lock.Unlock()
+	if len(val) != to-from {
+		panic(NewTextError(from+len(val), "Value does not match the length of the range."))
+	}
+
+	for i,x := range val {
+		if err := p.s.SetAt(from+i, x); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// AddBinder adds a Binder to be executed when a value in the range represented
+// by p changes. This is equivalent to calling AddRangeBinder on the
+// underlying Trigger.
+func (p *Span) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	p.s.AddRangeBinder(p.from, p.to, b, f, concurrent)
+}
@@ -0,0 +1,177 @@
+package slice
+
+import (
+	"testing"
+	"reflect"
+
+	"github.com/KellenWatt/reactor"
+)
+
+func TestElementBinding(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var count int
+	bindFunc := func(v interface{}) interface{} {
+		count += 1
+		return v.(Index).Value
+	}
+
+	trigger.SetValue([]interface{}{1,2,3,4,5})
+	ind.AddBinding(trigger.Element(3), bindFunc)
+
+	trigger.SetAt(1, 10)
+	if count != 0 {
+		t.Fatalf("Binding on index 3 run for change to index 1")
+	}
+
+	trigger.SetAt(3, 40)
+	if count != 1 || ind.Value() != 40 {
+		t.Fatalf("Expected binding to run once with value 40; ran %d times with value %v", count, ind.Value())
+	}
+
+	trigger.Append(6)
+	if count != 1 {
+		t.Fatalf("Binding on index 3 run for Append")
+	}
+}
+
+func TestElementBindingLast(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+
+	trigger.SetValue([]interface{}{1,2,3})
+	ind.AddBinding(trigger.Element(-1), reactor.TrivialBinding)
+
+	trigger.Append(4)
+	if ind.Value() != (Index{3, 4}) {
+		t.Fatalf("Expected %v after Append; got %v", Index{3, 4}, ind.Value())
+	}
+
+	trigger.Pop()
+	trigger.Pop()
+	if ind.Value() != (Index{1, 2}) {
+		t.Fatalf("Expected %v after Pop; got %v", Index{1, 2}, ind.Value())
+	}
+
+	trigger.SetAt(0, 10)
+	if ind.Value() != (Index{1, 2}) {
+		t.Fatalf("Binding on last index changed by SetAt(0); got %v", ind.Value())
+	}
+
+	trigger.Pop()
+	trigger.Pop()
+	if ind.Value() != (Index{-1, nil}) {
+		t.Fatalf("Expected %v for empty slice; got %v", Index{-1, nil}, ind.Value())
+	}
+}
+
+func TestElementBindingRemoved(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+
+	trigger.SetValue([]interface{}{1,2,3})
+	ind.AddBinding(trigger.Element(2), reactor.TrivialBinding)
+
+	trigger.Pop()
+	if ind.Value() != (Index{-1, nil}) {
+		t.Fatalf("Expected %v after removing index; got %v", Index{-1, nil}, ind.Value())
+	}
+
+	trigger.Append(30)
+	if ind.Value() != (Index{2, 30}) {
+		t.Fatalf("Expected %v after restoring index; got %v", Index{2, 30}, ind.Value())
+	}
+}
+
+func TestElementSetValue(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue([]interface{}{1,2,3})
+
+	trigger.Element(-1).SetValue(30)
+	if got,_ := trigger.At(2); got != 30 {
+		t.Fatalf("Expected last element to be 30; got %v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic when setting an index that does not exist")
+		}
+	}()
+	trigger.Element(3).SetValue(40)
+}
+
+func TestRangeBinding(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+	var count int
+	bindFunc := func(v interface{}) interface{} {
+		count += 1
+		return v
+	}
+
+	trigger.SetValue([]interface{}{1,2,3,4,5})
+	ind.AddBinding(trigger.Range(1, 3), bindFunc)
+
+	trigger.SetAt(4, 50)
+	trigger.Append(6)
+	if count != 0 {
+		t.Fatalf("Range binding run for changes outside of range")
+	}
+
+	trigger.SetAt(2, 30)
+	want := []interface{}{2,30}
+	if count != 1 || !reflect.DeepEqual(ind.Value(), want) {
+		t.Fatalf("Expected binding to run once with %v; ran %d times with %v", want, count, ind.Value())
+	}
+
+	trigger.SetValue([]interface{}{1})
+	want = []interface{}{}
+	if count != 2 || !reflect.DeepEqual(ind.Value(), want) {
+		t.Fatalf("Expected binding to run with %v; ran %d times with %v", want, count, ind.Value())
+	}
+}
+
+func TestRangeBindingTail(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+
+	trigger.SetValue([]interface{}{1,2,3})
+	ind.AddBinding(trigger.Range(-2, trigger.Size()+100), reactor.TrivialBinding)
+
+	trigger.Append(4)
+	want := []interface{}{3,4}
+	if !reflect.DeepEqual(ind.Value(), want) {
+		t.Fatalf("Expected %v; got %v", want, ind.Value())
+	}
+}
+
+func TestRangeSetValue(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue([]interface{}{1,2,3,4})
+
+	trigger.Range(1, 3).SetValue([]interface{}{20,30})
+	want := []interface{}{1,20,30,4}
+	if !reflect.DeepEqual(trigger.Value(), want) {
+		t.Fatalf("Expected %v; got %v", want, trigger.Value())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic when value does not match range length")
+		}
+	}()
+	trigger.Range(1, 3).SetValue([]interface{}{1})
+}
+
+func TestElementDelayedBinding(t *testing.T) {
+	var trigger Trigger
+	var ind reactor.Indicator
+
+	trigger.SetValue([]interface{}{1,2,3})
+	ind.AddDelayedBinding(trigger.Element(0), reactor.TrivialBinding)
+
+	trigger.SetAt(0, 10)
+	if ind.Value() != (Index{0, 10}) {
+		t.Fatalf("Expected %v; got %v", Index{0, 10}, ind.Value())
+	}
+}
@@ -0,0 +1,34 @@
+package slice
+
+import (
+	"fmt"
+	"strings"
+)
+
+func ExampleIndicator() {
+	var todos Trigger
+	var pending Indicator
+
+	pending.AddBinding(&todos, func(v interface{}) interface{} {
+		out := []interface{}{}
+		for _,item := range v.([]interface{}) {
+			if !strings.HasPrefix(item.(string), "[x]") {
+				out = append(out, item)
+			}
+		}
+		return out
+	})
+
+	pending.AddIndexReadCallback(IndexReadCallback(func(i int, v interface{}) {
+		fmt.Printf("Pending item %d: %v\n", i, v)
+	}))
+
+	todos.SetValue([]interface{}{"[x] write tests", "[ ] write docs", "[ ] release"})
+
+	for i:=0; i<pending.Size(); i++ {
+		pending.At(i)
+	}
+	// Output:
+	// Pending item 0: [ ] write docs
+	// Pending item 1: [ ] release
+}
@@ -0,0 +1,29 @@
+package slice
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// AppendLines reads r line by line, appending each line to s as a string, as 
+// if by Append. Line endings, either "\n" or "\r\n", are not included, and a 
+// final line without one is appended as well. Lines may be of any length, 
+// since each is read in full before it is appended. AppendLines blocks until 
+// r returns io.EOF, in which case the error is nil, or any other error, which 
+// is returned.
+func AppendLines(r io.Reader, s *Trigger) error {
+	reader := bufio.NewReader(r)
+	for {
+		line,err := reader.ReadString('\n')
+		if err == nil || line != "" {
+			s.Append(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package slice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppendLines(t *testing.T) {
+	var trigger Trigger
+	var appended []interface{}
+
+	trigger.AddIndexWriteCallback(IndexWriteCallback(func(_ int, _ interface{}, i int, v interface{}) {
+		appended = append(appended, v)
+	}))
+
+	err := AppendLines(strings.NewReader("first\nsecond\r\n\nlast"), &trigger)
+	if err != nil {
+		t.Fatalf("Expected nil error; got %v", err)
+	}
+
+	want := []interface{}{"first", "second", "", "last"}
+	if !reflect.DeepEqual(trigger.Value(), want) || !reflect.DeepEqual(appended, want) {
+		t.Fatalf("Expected %v; got %v with callbacks for %v", want, trigger.Value(), appended)
+	}
+}
+
+func TestAppendLinesLong(t *testing.T) {
+	var trigger Trigger
+	long := strings.Repeat("x", 1 << 20)
+
+	err := AppendLines(strings.NewReader(long + "\nafter\n"), &trigger)
+	if err != nil {
+		t.Fatalf("Expected nil error; got %v", err)
+	}
+	want := []interface{}{long, "after"}
+	if !reflect.DeepEqual(trigger.Value(), want) {
+		t.Fatalf("Expected the long line and the line after it; got %d lines", trigger.Size())
+	}
+}
@@ -0,0 +1,166 @@
+package slice
+
+import (
+	"github.com/KellenWatt/reactor"
+)
+
+// Indicator implements reactor.Binder for the special case of slices. An
+// Indicator provides all of the functionality of Trigger, including
+// index-level access and callbacks, but its value can also be bound to one or
+// more reactor.Initiators.
+//
+// Every BindingFunc used to bind an Indicator must return an []interface{},
+// or setting the value of the Indicator will panic, as described for
+// Trigger.SetValue.
+type Indicator struct {
+	Trigger
+
+	err error
+	errVersion uint64 // version of s when err was recorded
+	propagateErrors bool
+	errorCallbacks []reactor.ErrorCallback
+
+	delayedBindings []reactor.Binding
+}
+
+// evaluates any delayed bindings of s, settling its value to the result.
+func (s *Indicator) resolve() {
+	var v interface{}
+	for _,b := range s.delayedBindings {
+		v = reactor.RunDelayed(&s.Probe, b)
+	}
+
+	if len(s.delayedBindings) > 0 {
+		reactor.Settle(s, v)
+	}
+}
+
+// Value returns a copy of the full slice underlying s. Additionally, delayed
+// bindings associated with s will be evaluated before the value is read.
+//
+// Value calls any Callbacks registered with AddReadCallback, passing a copy of
+// the slice underlying s.
+func (s *Indicator) Value() interface{} {
+	s.resolve()
+	return s.Trigger.Value()
+}
+
+// ValueVersion returns a copy of the full slice underlying s, as if by Value, 
+// along with the version of s when it was read.
+func (s *Indicator) ValueVersion() (interface{}, uint64) {
+	s.resolve()
+	return s.Trigger.ValueVersion()
+}
+
+// At returns the value at index, as described for Trigger.At. Delayed bindings
+// associated with s will be evaluated before the value is read.
+func (s *Indicator) At(index int) (interface{}, error) {
+	s.resolve()
+	return s.Trigger.At(index)
+}
+
+// Slice returns a slice of s with bounds of [from, to), as described for
+// Trigger.Slice. Delayed bindings associated with s will be evaluated before
+// the value is read.
+func (s *Indicator) Slice(from, to int) ([]interface{}, error) {
+	s.resolve()
+	return s.Trigger.Slice(from, to)
+}
+
+// Size returns the size of the slice underlying s. Delayed bindings associated
+// with s will be evaluated first, but no ReadCallbacks will be triggered.
+func (s *Indicator) Size() int {
+	s.resolve()
+	return s.Trigger.Size()
+}
+
+// AddBinding binds s to i, with the value of s being determined by calling f
+// with the value of i.
+func (s *Indicator) AddBinding(i reactor.Initiator, f reactor.BindingFunc) {
+	i.AddBinder(s, f, false)
+}
+
+// AddDelayedBinding binds s to i, but the value of s is only determined when
+// it is read using Value, At, Slice, or Size. Because of this, f is only called
+// at the last possible moment. Consequently, this binding behaves differently
+// from the others, and any side effects will be affected as such.
+func (s *Indicator) AddDelayedBinding(i reactor.Initiator, f reactor.BindingFunc) {
+	s.delayedBindings = append(s.delayedBindings, reactor.Binding{Source: i, Binder: s, F: reactor.WatchBinding(&s.Probe, reactor.DelayedBindingEvent, i, f)})
+}
+
+// AddConcurrentBinding binds s to i, with the value of s being eventually
+// determined by i. The value passed to f when it is eventually called is the
+// value of i immediately after it triggers the binding, to ensure consistency.
+func (s *Indicator) AddConcurrentBinding(i reactor.Initiator, f reactor.BindingFunc) {
+	i.AddBinder(s, reactor.QueueBinding(s, f), true)
+}
+
+// AddFallibleBinding binds s to i, with the value of s being determined by
+// calling f with the value of i. If f returns a non-nil error, s keeps its
+// previous value and the error is recorded as if by Fail.
+func (s *Indicator) AddFallibleBinding(i reactor.Initiator, f reactor.FallibleBindingFunc) {
+	i.AddBinder(s, reactor.FallibleBinding(f), false)
+}
+
+// Err returns the error recorded by the most recent failed fallible binding
+// of s. If s has been written to since that failure, either as a whole or at
+// a single index, Err returns nil.
+func (s *Indicator) Err() error {
+	s.Lock.Lock()
+	defer s.Lock.Unlock()
+	if s.version != s.errVersion {
+		return nil
+	}
+	return s.err
+}
+
+// Fail records err as the error of s without changing its value, and runs
+// any callbacks registered with AddErrorCallback. If error propagation is
+// enabled for s, err is also passed to Fail for any dependent Binders,
+// including those bound to single indices or ranges, that implement
+// reactor.FallibleBinder.
+//
+// Fail is largely intended for propagating errors between Binders, and its
+// use is discouraged outside of that. Failed fallible bindings call Fail
+// automatically.
+func (s *Indicator) Fail(err error) {
+	s.Lock.Lock()
+		s.err = err
+		s.errVersion = s.version
+		propagate := s.propagateErrors
+		binders := make([]reactor.Binder, 0, len(s.bindings)+len(s.indexBindings))
+		for _,b := range s.bindings {
+			binders = append(binders, b.Binder)
+		}
+		for _,b := range s.indexBindings {
+			binders = append(binders, b.Binder)
+		}
+	s.Lock.Unlock()
+
+	for _,c := range s.errorCallbacks {
+		c(err)
+	}
+
+	if !propagate {
+		return
+	}
+	for _,b := range binders {
+		if f,ok := b.(reactor.FallibleBinder); ok {
+			f.Fail(err)
+		}
+	}
+}
+
+// PropagateErrors sets whether errors recorded by s are passed on to the
+// Binders that depend on s. Errors are not propagated by default.
+func (s *Indicator) PropagateErrors(propagate bool) {
+	s.Lock.Lock()
+		s.propagateErrors = propagate
+	s.Lock.Unlock()
+}
+
+// AddErrorCallback adds a callback that will be run when a fallible binding
+// of s fails, or an error is propagated to s.
+func (s *Indicator) AddErrorCallback(e reactor.ErrorCallback) {
+	s.errorCallbacks = append(s.errorCallbacks, e)
+}
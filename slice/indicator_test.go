@@ -0,0 +1,192 @@
+package slice
+
+import (
+	"errors"
+	"testing"
+	"reflect"
+
+	"github.com/KellenWatt/reactor"
+)
+
+func evens(v interface{}) interface{} {
+	var out []interface{}
+	for _,x := range v.([]interface{}) {
+		if x.(int) % 2 == 0 {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func TestIndicatorImplementsBinder(t *testing.T) {
+	var _ reactor.Binder = &Indicator{}
+	var _ reactor.FallibleBinder = &Indicator{}
+}
+
+func TestIndicatorAddBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+
+	ind.AddBinding(&trigger, evens)
+	trigger.SetValue([]interface{}{1,2,3,4})
+
+	want := []interface{}{2,4}
+	if !reflect.DeepEqual(ind.Value(), want) {
+		t.Fatalf("Expected %v; got %v", want, ind.Value())
+	}
+
+	trigger.Append(6)
+	if !reflect.DeepEqual(ind.Value(), want) {
+		t.Fatalf("Binding should not run for index-level change; got %v", ind.Value())
+	}
+}
+
+func TestIndicatorIndexAccess(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	var written []Index
+
+	ind.AddBinding(&trigger, evens)
+	ind.AddIndexWriteCallback(func(prev, v interface{}) {
+		written = append(written, v.(Index))
+	})
+	trigger.SetValue([]interface{}{1,2,3,4})
+
+	ind.Append(8)
+	ind.SetAt(0, 0)
+	got,_ := ind.At(2)
+	if got != 8 {
+		t.Fatalf("Expected 8 at index 2; got %v", got)
+	}
+
+	want := []Index{{2, 8}, {0, 0}}
+	if !reflect.DeepEqual(written, want) {
+		t.Fatalf("Expected index callbacks %v; got %v", want, written)
+	}
+}
+
+func TestIndicatorChainBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	var last reactor.Indicator
+
+	ind.AddBinding(&trigger, evens)
+	last.AddBinding(ind.Element(-1), func(v interface{}) interface{} {
+		return v.(Index).Value
+	})
+
+	trigger.SetValue([]interface{}{1,2,3,4})
+	if last.Value() != 4 {
+		t.Fatalf("Expected last even value 4; got %v", last.Value())
+	}
+}
+
+func TestIndicatorDelayedBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	var count int
+
+	ind.AddDelayedBinding(&trigger, func(v interface{}) interface{} {
+		count += 1
+		return evens(v)
+	})
+
+	trigger.SetValue([]interface{}{1,2,3,4})
+	trigger.SetValue([]interface{}{4,5,6})
+	if count != 0 {
+		t.Fatal("Delayed binding executed without a read")
+	}
+
+	if ind.Size() != 2 || count != 1 {
+		t.Fatalf("Expected size 2 after 1 evaluation; got %d after %d", ind.Size(), count)
+	}
+
+	got,_ := ind.Slice(0, 2)
+	want := []interface{}{4,6}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v; got %v", want, got)
+	}
+}
+
+func TestIndicatorDelayedBindingUnchanged(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	bad := errors.New("empty")
+	ind.AddDelayedBinding(&trigger, reactor.FallibleBinding(func(v interface{}) (interface{}, error) {
+		switch len(v.([]interface{})) {
+		case 0:
+			return nil, bad
+		case 1:
+			return reactor.Unchanged, nil
+		}
+		return v, nil
+	}))
+
+	trigger.SetValue([]interface{}{1,2})
+	ind.Value()
+	trigger.SetValue([]interface{}{3})
+	if !reflect.DeepEqual(ind.Value(), []interface{}{1,2}) {
+		t.Fatalf("Expected Unchanged to keep [1 2]; got %v", ind.Value())
+	}
+	trigger.SetValue([]interface{}{})
+	if !reflect.DeepEqual(ind.Value(), []interface{}{1,2}) || ind.Err() != bad {
+		t.Fatalf("Expected failure to keep [1 2] and record its error; got %v, %v", ind.Value(), ind.Err())
+	}
+}
+
+func TestIndicatorConcurrentBinding(t *testing.T) {
+	var trigger Trigger
+	var ind Indicator
+	done := make(chan bool)
+
+	ind.AddConcurrentBinding(&trigger, evens)
+	ind.AddWriteCallback(func(prev, v interface{}) {
+		done <- true
+	})
+
+	trigger.SetValue([]interface{}{1,2})
+	<-done
+
+	want := []interface{}{2}
+	if !reflect.DeepEqual(ind.Value(), want) {
+		t.Fatalf("Expected %v; got %v", want, ind.Value())
+	}
+}
+
+func TestIndicatorFallibleBinding(t *testing.T) {
+	var trigger reactor.Trigger
+	var ind Indicator
+	var elem reactor.Indicator
+	var errs []error
+	bad := errors.New("not a slice")
+
+	ind.AddFallibleBinding(&trigger, func(v interface{}) (interface{}, error) {
+		s,ok := v.([]interface{})
+		if !ok {
+			return nil, bad
+		}
+		return s, nil
+	})
+	ind.AddErrorCallback(func(err error) {
+		errs = append(errs, err)
+	})
+	elem.AddBinding(ind.Element(0), reactor.TrivialBinding)
+	ind.PropagateErrors(true)
+
+	trigger.SetValue([]interface{}{1,2})
+	trigger.SetValue(3)
+	if !reflect.DeepEqual(ind.Value(), []interface{}{1,2}) {
+		t.Fatalf("Failed binding should keep previous value; got %v", ind.Value())
+	}
+	if ind.Err() != bad || len(errs) != 1 {
+		t.Fatalf("Expected one error; got %v with %d callbacks", ind.Err(), len(errs))
+	}
+	if elem.Err() != bad {
+		t.Fatalf("Expected error to propagate to index binding; got %v", elem.Err())
+	}
+
+	ind.Append(3)
+	if ind.Err() != nil {
+		t.Fatalf("Expected error to be cleared by a write; got %v", ind.Err())
+	}
+}
@@ -0,0 +1,29 @@
+package slice
+
+import (
+	"encoding/json"
+)
+
+// MarshalJSON implements json.Marshaler, encoding the value of s as a JSON
+// array. The value is read as if by Value, so any read callbacks are run.
+func (s *Trigger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Value())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding data as a JSON array
+// and setting s to the result as if by SetValue, so any callbacks and bindings
+// are run. Elements are decoded as they would be into an interface{}.
+func (s *Trigger) UnmarshalJSON(data []byte) error {
+	var v []interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	s.SetValue(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, as described for Trigger.MarshalJSON.
+// Any delayed bindings are evaluated first.
+func (s *Indicator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Value())
+}
@@ -0,0 +1,58 @@
+package slice
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTriggerJSON(t *testing.T) {
+	var s Trigger
+	s.SetValue([]interface{}{1, "two", 3.5})
+
+	data,err := json.Marshal(&s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != `[1,"two",3.5]` {
+		t.Fatalf("Expected [1,\"two\",3.5]; got %s", data)
+	}
+
+	var copied Trigger
+	var written int
+	copied.AddWriteCallback(func(prev, v interface{}) {
+		written += 1
+	})
+	if err := json.Unmarshal(data, &copied); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	v,_ := copied.At(1)
+	if copied.Size() != 3 || v != "two" || written != 1 {
+		t.Fatalf("Expected 3 elements set through SetValue; got %v with %d writes", copied.Value(), written)
+	}
+
+	if err := json.Unmarshal([]byte(`{"a":1}`), &copied); err == nil {
+		t.Fatal("Expected error decoding object into slice Trigger")
+	}
+}
+
+func TestIndicatorMarshalJSON(t *testing.T) {
+	var s Trigger
+	var doubled Indicator
+	s.SetValue([]interface{}{1, 2})
+	doubled.AddDelayedBinding(&s, func(v interface{}) interface{} {
+		var out []interface{}
+		for _,x := range v.([]interface{}) {
+			out = append(out, x.(int) * 2)
+		}
+		return out
+	})
+	s.SetValue([]interface{}{1, 2, 3})
+
+	data,err := json.Marshal(&doubled)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != `[2,4,6]` {
+		t.Fatalf("Expected delayed binding to be resolved as [2,4,6]; got %s", data)
+	}
+}
@@ -4,6 +4,7 @@
 package slice
 
 import (
+	"context"
 	"sync"
 	"fmt"
 
@@ -53,7 +54,9 @@ type Index struct {
 // handling that is beyond the scope of this package.
 type Trigger struct {
 	Lock sync.Mutex
+	reactor.Probe
 	value []interface{}
+	version uint64
 
 	readCallbacks []reactor.ReadCallback
 	writeCallbacks []reactor.WriteCallback
@@ -61,7 +64,24 @@ type Trigger struct {
 	indexReadCallbacks []reactor.ReadCallback
 	indexWriteCallbacks []reactor.WriteCallback
 
+	watchers reactor.Watchers
+	indexWatchers reactor.Watchers
+
 	bindings []reactor.Binding
+	indexBindings []indexBinding
+}
+
+// collects the index bindings of s affected by a change to the indices 
+// [from, to) that changed the length of s from prevLen, resulting in v. If v 
+// is the slice underlying s, this must be called while holding s.Lock.
+func (s *Trigger) affected(v []interface{}, from, to, prevLen int) []pendingBinding {
+	var pending []pendingBinding
+	for _,b := range s.indexBindings {
+		if b.affected(from, to, prevLen, len(v)) {
+			pending = append(pending, pendingBinding{b.Binding, b.value(v)})
+		}
+	}
+	return pending
 }
 
 // Value returns a copy of the full slice underlying s.
@@ -69,16 +89,26 @@ type Trigger struct {
 // Value calls any Callbacks registered with AddReadCallback, passing a copy of
 // the slice underlying s.
 func (s *Trigger) Value() interface{} {
+	v,_ := s.ValueVersion()
+	return v
+}
+
+// ValueVersion returns a copy of the full slice underlying s, as if by Value, 
+// along with the version of s when it was read.
+func (s *Trigger) ValueVersion() (interface{}, uint64) {
 	s.Lock.Lock()
 		v := make([]interface{}, len(s.value))
 		copy(v, s.value)
+		version := s.version
 	s.Lock.Unlock()
 
+	start := reactor.StartEvent(&s.Probe)
 	for _,c := range s.readCallbacks {
 		c(v)
 	}
+	reactor.EmitEvent(&s.Probe, reactor.Event{Kind: reactor.ReadEvent, Value: v}, start)
 
-	return v
+	return v, version
 }
 
 // SetValue sets the underlying slice of s to a copy of v. v is tested to 
@@ -86,26 +116,117 @@ func (s *Trigger) Value() interface{} {
 // proper type, SetValue panics.
 //
 // SetValue calls any callbacks registered with AddWriteCallback, passing
-// a copy of the previous slice and v (as an []interface{}).
+// a copy of the previous slice and v (as an []interface{}). All index-level 
+// bindings covering an index of either slice are run. If SetValue is called 
+// during a reactor.Batch, callbacks and bindings are held back until the 
+// Batch ends.
 func (s *Trigger) SetValue(v interface{}) {
+	val := v.([]interface{})
+	s.Lock.Lock()
+		prev := s.set(val)
+	s.Lock.Unlock()
+
+	if reactor.Batched(s, prev, val, s.propagate) {
+		return
+	}
+	s.propagate(prev, val)
+}
+
+// sets the underlying slice of s to a copy of v, returning a copy of the 
+// previous slice. Must be called while holding s.Lock.
+func (s *Trigger) set(v []interface{}) []interface{} {
+	prev := make([]interface{}, len(s.value))
+	copy(prev, s.value)
+	s.value = make([]interface{}, len(v))
+	copy(s.value, v)
+	s.version++
+	return prev
+}
+
+// Version returns the version of s, which is increased every time s is 
+// written to, whether by SetValue or an index-level method.
+func (s *Trigger) Version() uint64 {
+	s.Lock.Lock()
+		version := s.version
+	s.Lock.Unlock()
+	return version
+}
+
+// CompareAndSet sets the underlying slice of s to a copy of v, as if by 
+// SetValue, but only if the version of s is still version. CompareAndSet 
+// reports whether the value was set. No callbacks are run if it was not.
+func (s *Trigger) CompareAndSet(version uint64, v interface{}) bool {
+	val := v.([]interface{})
+	s.Lock.Lock()
+		ok := s.version == version
+		var prev []interface{}
+		if ok {
+			prev = s.set(val)
+		}
+	s.Lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	if !reactor.Batched(s, prev, val, s.propagate) {
+		s.propagate(prev, val)
+	}
+	return true
+}
+
+// Update sets the underlying slice of s to the result of calling f with a copy
+// of the current slice, and returns a copy of the new slice. f must return an 
+// []interface{}, or Update panics. f is called while holding s.Lock, so no 
+// other write can happen between reading and writing, but f must not access s 
+// itself. Callbacks and bindings are run as if by SetValue, after the lock is 
+// released. No read callbacks are run. If f returns reactor.Unchanged, s is 
+// not written, no callbacks are run, and a copy of the current slice is 
+// returned.
+func (s *Trigger) Update(f func(interface{}) interface{}) interface{} {
 	s.Lock.Lock()
-		val := v.([]interface{})
-		prev := make([]interface{}, len(s.value))
-		copy(prev, s.value)
-		s.value = make([]interface{}, len(val))
-		copy(s.value, val)
+		cur := make([]interface{}, len(s.value))
+		copy(cur, s.value)
+		next := f(cur)
+		if next == reactor.Unchanged {
+			copy(cur, s.value)
+			s.Lock.Unlock()
+			return cur
+		}
+		val := next.([]interface{})
+		prev := s.set(val)
+		result := make([]interface{}, len(val))
+		copy(result, val)
 	s.Lock.Unlock()
 
+	if !reactor.Batched(s, prev, val, s.propagate) {
+		s.propagate(prev, val)
+	}
+	return result
+}
+
+// runs the write callbacks and bindings of s for a change from prev to v.
+func (s *Trigger) propagate(prev, v interface{}) {
+	prevVal := prev.([]interface{})
+	val := v.([]interface{})
+	size := len(val)
+	if len(prevVal) > size {
+		size = len(prevVal)
+	}
+	pending := s.affected(val, 0, size, len(prevVal))
+
+	start := reactor.StartEvent(&s.Probe)
 	for _,c := range s.writeCallbacks {
 		c(prev, val)
 	}
 
+	s.watchers.Notify(prev, val)
+
 	for _,b := range s.bindings {
-		val := b.F(v)
-		if !b.Concurrent {
-			b.Binder.SetValue(val)
-		}
+		reactor.RunBinding(&s.Probe, b, v)
 	}
+
+	runPending(&s.Probe, pending)
+	reactor.EmitEvent(&s.Probe, reactor.Event{Kind: reactor.WriteEvent, Prev: prev, Value: val}, start)
 }
 
 // AddBinder adds a Binder to be executed when the value of s changes. If 
@@ -113,8 +234,8 @@ func (s *Trigger) SetValue(v interface{}) {
 // will be queued for change.
 //
 // Note that Bindings are not be executed for index-level changes. As such, any
-// Binder that is relying on such a change would best be served by using a 
-// delayed binding.
+// Binder that is relying on such a change would best be served by binding to 
+// an Element or Span, or by using a delayed binding.
 //
 // AddBinder is largely intended for use in implementing Binders, and its 
 // use is heavily discouraged outside of that. If concurrent is true outside 
@@ -122,21 +243,61 @@ func (s *Trigger) SetValue(v interface{}) {
 // If concurrent is false, this will have exactly the same effect as 
 // Binder.AddBinding(), which is the preferred method of creating bindings.
 func (s *Trigger) AddBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
-	s.bindings = append(s.bindings, reactor.Binding{s, b, f, concurrent})
+	s.bindings = append(s.bindings, reactor.Binding{Source: s, Binder: b, F: reactor.WatchBinding(&s.Probe, reactor.BindingEvent, b, f), Concurrent: concurrent})
+}
+
+// AddIndexBinder adds a Binder to be executed when the value at index of s 
+// changes, whether by SetAt, Append, Pop, or SetValue. A negative index is 
+// counted from the end of s, so bindings on index -1 follow the last element 
+// as s grows and shrinks. The value passed to f is the same as that returned 
+// by s.Element(index).Value.
+//
+// Like AddBinder, AddIndexBinder is largely intended for use in implementing 
+// Binders. Binding to the result of Element is the preferred method of 
+// creating index-level bindings.
+func (s *Trigger) AddIndexBinder(index int, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	binding := reactor.Binding{Source: s.Element(index), Binder: b, F: reactor.WatchBinding(&s.Probe, reactor.BindingEvent, b, f), Concurrent: concurrent}
+	s.indexBindings = append(s.indexBindings, indexBinding{index, index+1, true, binding})
+}
+
+// AddRangeBinder adds a Binder to be executed when a value within the range 
+// [from, to) of s changes, or when the indices covered by the range change. 
+// Bounds are resolved as described for Span. The value passed to f is the same 
+// as that returned by s.Range(from, to).Value.
+//
+// Like AddBinder, AddRangeBinder is largely intended for use in implementing 
+// Binders. Binding to the result of Range is the preferred method of 
+// creating range bindings.
+func (s *Trigger) AddRangeBinder(from, to int, b reactor.Binder, f reactor.BindingFunc, concurrent bool) {
+	binding := reactor.Binding{Source: s.Range(from, to), Binder: b, F: reactor.WatchBinding(&s.Probe, reactor.BindingEvent, b, f), Concurrent: concurrent}
+	s.indexBindings = append(s.indexBindings, indexBinding{from, to, false, binding})
 }
 
-// runs from SetAt. Currently unsupported 
-// funcAddIndexBinder(b reactor.Binder, f reactor.BindingFunc, concurrent bool)
+// Watch returns a channel that receives a Change for every write to s using 
+// SetValue, until ctx is cancelled. The values of each Change are the same as 
+// those passed to callbacks registered with AddWriteCallback. The channel is 
+// closed once ctx is cancelled.
+func (s *Trigger) Watch(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return s.watchers.Add(ctx, mode)
+}
+
+// WatchIndex returns a channel that receives a Change for every index-level 
+// write to s, until ctx is cancelled. The values of each Change are the same 
+// Index structs passed to callbacks registered with AddIndexWriteCallback. The 
+// channel is closed once ctx is cancelled.
+func (s *Trigger) WatchIndex(ctx context.Context, mode reactor.WatchMode) <-chan reactor.Change {
+	return s.indexWatchers.Add(ctx, mode)
+}
 
 // AddReadCallback adds a callback that will be run when s is read using Value.
 func (s *Trigger) AddReadCallback(r reactor.ReadCallback) {
-	s.readCallbacks = append(s.readCallbacks, r)
+	s.readCallbacks = append(s.readCallbacks, reactor.WatchRead(&s.Probe, reactor.ReadEvent, r))
 }
 
 // AddReadCallback adds a callback that will be run when s is written to using 
 // SetValue.
 func (s *Trigger) AddWriteCallback(w reactor.WriteCallback) {
-	s.writeCallbacks = append(s.writeCallbacks, w)
+	s.writeCallbacks = append(s.writeCallbacks, reactor.WatchWrite(&s.Probe, reactor.WriteEvent, w))
 }
 
 // At returns the value at index, if index is within range of the underlying 
@@ -157,9 +318,11 @@ func (s *Trigger) At(index int) (interface{}, error) {
 		return nil, NewError(index)
 	}
 
+	start := reactor.StartEvent(&s.Probe)
 	for _,c := range s.indexReadCallbacks {
 		c(Index{index, v})
 	}
+	reactor.EmitEvent(&s.Probe, reactor.Event{Kind: reactor.IndexReadEvent, Value: Index{index, v}}, start)
 
 	return v, nil
 }
@@ -175,18 +338,19 @@ func (s *Trigger) SetAt(index int, v interface{}) error {
 	s.Lock.Lock()
 		valid := index >= 0 && index < len(s.value)
 		var prev interface{}
+		var pending []pendingBinding
 		if valid {
 			prev = s.value[index]
 			s.value[index] = v
+			s.version++
+			pending = s.affected(s.value, index, index+1, len(s.value))
 		}
 	s.Lock.Unlock()
 	if !valid {
 		return NewError(index)
 	}
 
-	for _,c := range s.indexWriteCallbacks {
-		c(Index{index, prev}, Index{index, v})
-	}
+	s.indexWritten(Index{index, prev}, Index{index, v}, pending)
 
 	return nil
 }
@@ -202,11 +366,12 @@ func (s *Trigger) SetAt(index int, v interface{}) error {
 func (s *Trigger) Append(v interface{}) {
 	s.Lock.Lock()
 		s.value = append(s.value, v)
+		s.version++
+		index := len(s.value)-1
+		pending := s.affected(s.value, index, index+1, index)
 	s.Lock.Unlock()
 	
-	for _,c := range s.indexWriteCallbacks {
-		c(Index{-1, nil}, Index{len(s.value)-1, v})
-	}
+	s.indexWritten(Index{-1, nil}, Index{index, v}, pending)
 }
 
 // Pop removes the highest-index value from the end of s and returns that value.
@@ -223,9 +388,14 @@ func (s *Trigger) Pop() (interface{}, error) {
 	s.Lock.Lock()
 		valid := len(s.value) > 0
 		var v interface{}
+		var index int
+		var pending []pendingBinding
 		if valid {
-			v = s.value[len(s.value)-1]
-			s.value = s.value[:len(s.value)-1]
+			index = len(s.value)-1
+			v = s.value[index]
+			s.value = s.value[:index]
+			s.version++
+			pending = s.affected(s.value, index, index+1, index+1)
 		}
 	s.Lock.Unlock()
 	if !valid {
@@ -233,13 +403,28 @@ func (s *Trigger) Pop() (interface{}, error) {
 		return nil, NewTextError(0, "Attempt to Pop from an empty slice.")
 	}
 
-	for _,c := range s.indexWriteCallbacks {
-		c(Index{len(s.value), v}, Index{-1, nil})
-	}
+	s.indexWritten(Index{index, v}, Index{-1, nil}, pending)
 
 	return v, nil
 }
 
+// runs the index-level write callbacks and watchers of s for a change from 
+// prev to v, followed by the pending index bindings. During a reactor.Batch, 
+// they are held back until the Batch ends.
+func (s *Trigger) indexWritten(prev, v Index, pending []pendingBinding) {
+	reactor.Defer(func() {
+		start := reactor.StartEvent(&s.Probe)
+		for _,c := range s.indexWriteCallbacks {
+			c(prev, v)
+		}
+
+		s.indexWatchers.Notify(prev, v)
+
+		runPending(&s.Probe, pending)
+		reactor.EmitEvent(&s.Probe, reactor.Event{Kind: reactor.IndexWriteEvent, Prev: prev, Value: v}, start)
+	})
+}
+
 // Slice returns a slice of s with bounds of [from, to). This slice will be
 // a copy of that underlying s, and modifying it will have no impact on s. If
 // to is less than from, or from or to are outside the bounds of 
@@ -272,9 +457,11 @@ func (s *Trigger) Slice(from, to int) ([]interface{}, error) {
 		return nil, NewTextError(bad, badText)
 	}
 
+	start := reactor.StartEvent(&s.Probe)
 	for _,c := range s.readCallbacks {
 		c(v)
 	}
+	reactor.EmitEvent(&s.Probe, reactor.Event{Kind: reactor.ReadEvent, Value: v}, start)
 
 	return v, nil
 }
@@ -289,7 +476,7 @@ func (s *Trigger) Size() int {
 // index-level read events. The value passed to the callback will be 
 // an Index struct containing the index and value being read.
 func (s *Trigger) AddIndexReadCallback(r reactor.ReadCallback) {
-	s.indexReadCallbacks = append(s.indexReadCallbacks, r)
+	s.indexReadCallbacks = append(s.indexReadCallbacks, reactor.WatchRead(&s.Probe, reactor.IndexReadEvent, r))
 }
 
 // AddIndexWriteCallback adds a WriteCallback that will be triggered by any 
@@ -297,6 +484,6 @@ func (s *Trigger) AddIndexReadCallback(r reactor.ReadCallback) {
 // Index structs containing the index and previous and new values written, 
 // respectively.
 func (s *Trigger) AddIndexWriteCallback(w reactor.WriteCallback) {
-	s.indexWriteCallbacks = append(s.indexWriteCallbacks, w)
+	s.indexWriteCallbacks = append(s.indexWriteCallbacks, reactor.WatchWrite(&s.Probe, reactor.IndexWriteEvent, w))
 }
 
@@ -1,6 +1,7 @@
 package slice
 
 import (
+	"context"
 	"testing"
 	"reflect"
 	
@@ -394,3 +395,116 @@ func TestTriggerIndexWriteCallback(t *testing.T) {
 		t.Fatalf("Expected count to be %d after %d iterations; got %d", iters, iters, count)
 	}	
 }
+
+func TestTriggerVersion(t *testing.T) {
+	var trigger Trigger
+
+	trigger.SetValue([]interface{}{1})
+	trigger.Append(2)
+	trigger.SetAt(0, 0)
+	trigger.Pop()
+	if trigger.Version() != 4 {
+		t.Fatalf("Expected version 4 after four writes; got %d", trigger.Version())
+	}
+
+	if trigger.CompareAndSet(3, []interface{}{}) {
+		t.Fatal("CompareAndSet succeeded with stale version")
+	}
+	if !trigger.CompareAndSet(4, []interface{}{5}) {
+		t.Fatal("CompareAndSet failed with current version")
+	}
+}
+
+func TestTriggerUpdate(t *testing.T) {
+	var trigger Trigger
+	var written []interface{}
+	trigger.SetValue([]interface{}{1,2})
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		written = v.([]interface{})
+	})
+
+	got := trigger.Update(func(v interface{}) interface{} {
+		return append(v.([]interface{}), 3)
+	})
+
+	want := []interface{}{1,2,3}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(trigger.value, want) {
+		t.Fatalf("Expected %v; got %v with internal value %v", want, got, trigger.value)
+	}
+	if !reflect.DeepEqual(written, want) {
+		t.Fatalf("Expected write callback with %v; got %v", want, written)
+	}
+}
+
+func TestTriggerWatchIndex(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	values := trigger.Watch(ctx, reactor.Buffered(10))
+	indices := trigger.WatchIndex(ctx, reactor.Buffered(10))
+
+	trigger.SetValue([]interface{}{1})
+	trigger.Append(2)
+	trigger.Pop()
+
+	if c := <-values; !reflect.DeepEqual(c.Value, []interface{}{1}) {
+		t.Fatalf("Expected SetValue change; got %v", c)
+	}
+
+	want := []reactor.Change{
+		{Prev: Index{-1, nil}, Value: Index{1, 2}},
+		{Prev: Index{1, 2}, Value: Index{-1, nil}},
+	}
+	for _,w := range want {
+		if c := <-indices; c != w {
+			t.Fatalf("Expected %v; got %v", w, c)
+		}
+	}
+}
+
+func TestTriggerTraceIndexEvents(t *testing.T) {
+	var trigger Trigger
+	var events []reactor.Event
+	trigger.SetName("list")
+	trigger.SetTracer(reactor.TracerFunc(func(e reactor.Event) {
+		events = append(events, e)
+	}))
+
+	trigger.Append("a")
+	trigger.At(0)
+	trigger.Pop()
+
+	want := []reactor.EventKind{reactor.IndexWriteEvent, reactor.IndexReadEvent, reactor.IndexWriteEvent}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events; got %v", len(want), events)
+	}
+	for n,e := range events {
+		if e.Kind != want[n] || e.Source != "list" {
+			t.Fatalf("Event %d: Expected %v from list; got %+v", n, want[n], e)
+		}
+	}
+	if events[2].Prev != (Index{0, "a"}) || events[2].Value != (Index{-1, nil}) {
+		t.Fatalf("Unexpected pop event %+v", events[2])
+	}
+}
+
+func TestBatchHoldsIndexWrites(t *testing.T) {
+	var s Trigger
+	var written []Index
+	s.AddIndexWriteCallback(func(prev, v interface{}) {
+		written = append(written, v.(Index))
+	})
+
+	reactor.Batch(func() {
+		s.Append("a")
+		s.Append("b")
+		if len(written) != 0 {
+			t.Fatalf("Expected index writes to be held back; got %v", written)
+		}
+	})
+	want := []Index{{0, "a"}, {1, "b"}}
+	if !reflect.DeepEqual(written, want) {
+		t.Fatalf("Expected %v once the batch ended; got %v", want, written)
+	}
+}
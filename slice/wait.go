@@ -0,0 +1,61 @@
+package slice
+
+import (
+	"context"
+
+	"github.com/KellenWatt/reactor"
+)
+
+// waitBuffer is the number of changes queued for WaitForIndex while it is
+// evaluating its predicate.
+const waitBuffer = 16
+
+// WaitForIndex blocks until an element of s satisfies pred, and returns that
+// element and its index as an Index struct. Every element currently in s is
+// checked first, in order. After that, pred is called with every element
+// written to s, whether by SetAt, Append, or SetValue, until one satisfies it.
+// If ctx is cancelled or times out first, WaitForIndex returns Index{-1, nil}
+// and the error from ctx.
+func WaitForIndex(ctx context.Context, s *Trigger, pred func(Index) bool) (Index, error) {
+	ctx,cancel := context.WithCancel(ctx)
+	defer cancel()
+	// subscribe before checking, so no write is missed in between
+	values := s.Watch(ctx, reactor.Buffered(waitBuffer))
+	indices := s.WatchIndex(ctx, reactor.Buffered(waitBuffer))
+
+	check := func(v []interface{}) (Index, bool) {
+		for i,x := range v {
+			if pred(Index{i, x}) {
+				return Index{i, x}, true
+			}
+		}
+		return Index{-1, nil}, false
+	}
+
+	if i,ok := check(s.Value().([]interface{})); ok {
+		return i, nil
+	}
+
+	for {
+		select {
+		case c,ok := <-values:
+			if !ok {
+				return Index{-1, nil}, ctx.Err()
+			}
+			if i,ok := check(c.Value.([]interface{})); ok {
+				return i, nil
+			}
+		case c,ok := <-indices:
+			if !ok {
+				return Index{-1, nil}, ctx.Err()
+			}
+			// Pop leaves no element behind to check
+			i := c.Value.(Index)
+			if i.Key >= 0 && pred(i) {
+				return i, nil
+			}
+		case <-ctx.Done():
+			return Index{-1, nil}, ctx.Err()
+		}
+	}
+}
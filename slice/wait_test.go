@@ -0,0 +1,51 @@
+package slice
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForIndexCurrent(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue([]interface{}{"a", "b", "c"})
+
+	i,err := WaitForIndex(context.Background(), &trigger, func(i Index) bool {
+		return i.Value == "b"
+	})
+	if err != nil || i != (Index{1, "b"}) {
+		t.Fatalf("Expected (%v, <nil>); got (%v, %v)", Index{1, "b"}, i, err)
+	}
+}
+
+func TestWaitForIndex(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue([]interface{}{})
+	ctx,cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		trigger.Append("starting")
+		trigger.Append("ready")
+	}()
+
+	i,err := WaitForIndex(ctx, &trigger, func(i Index) bool {
+		return i.Value == "ready"
+	})
+	if err != nil || i != (Index{1, "ready"}) {
+		t.Fatalf("Expected (%v, <nil>); got (%v, %v)", Index{1, "ready"}, i, err)
+	}
+}
+
+func TestWaitForIndexTimeout(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_,err := WaitForIndex(ctx, &trigger, func(i Index) bool {
+		return i.Key == 5
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v; got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build go1.21
+// +build go1.21
+
+package reactor
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogValue returns the fields of e as a group of attributes, for logging
+// with log/slog. Target is only included for binding events, Prev is omitted
+// for reads, and ParentID is omitted for events that start a trace.
+func (e Event) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("source", e.Source),
+		slog.String("kind", e.Kind.String()),
+	}
+	if e.Target != "" {
+		attrs = append(attrs, slog.String("target", e.Target))
+	}
+	switch e.Kind {
+	case ReadEvent, IndexReadEvent, KeyReadEvent:
+	default:
+		attrs = append(attrs, slog.Any("prev", e.Prev))
+	}
+	attrs = append(attrs, slog.Any("value", e.Value), slog.Duration("duration", e.Duration),
+		slog.Uint64("trace", e.TraceID), slog.Uint64("span", e.SpanID))
+	if e.ParentID != 0 {
+		attrs = append(attrs, slog.Uint64("parent", e.ParentID))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// SlogTracer returns a Tracer that logs every Event to l at level, with the
+// message "reactor event" and the attributes returned by Event.LogValue.
+// Events are not built into records at all if l is not enabled for level.
+func SlogTracer(l *slog.Logger, level slog.Level) Tracer {
+	return TracerFunc(func(e Event) {
+		ctx := context.Background()
+		if !l.Enabled(ctx, level) {
+			return
+		}
+		l.LogAttrs(ctx, level, "reactor event", e.LogValue().Group()...)
+	})
+}
@@ -0,0 +1,55 @@
+//go:build go1.21
+// +build go1.21
+
+package reactor
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogTracer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var trigger Trigger
+	trigger.SetName("counter")
+	trigger.SetTracer(SlogTracer(logger, slog.LevelInfo))
+	trigger.SetValue(1)
+	trigger.SetValue(2)
+
+	var rec map[string]interface{}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records; got %q", buf.String())
+	}
+	if err := json.Unmarshal(lines[1], &rec); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"msg": "reactor event", "source": "counter", "kind": "write",
+		"prev": 1.0, "value": 2.0,
+	}
+	for k,v := range want {
+		if rec[k] != v {
+			t.Fatalf("Record field %q = %v; want %v (record %v)", k, rec[k], v, rec)
+		}
+	}
+	if _,ok := rec["duration"]; !ok {
+		t.Fatalf("Expected a duration; got %v", rec)
+	}
+}
+
+func TestSlogTracerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	var trigger Trigger
+	trigger.SetTracer(SlogTracer(logger, slog.LevelDebug))
+	trigger.SetValue(1)
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing logged below the handler level; got %q", buf.String())
+	}
+}
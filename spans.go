@@ -0,0 +1,146 @@
+package reactor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Span is a traced Event, along with the Events it caused.
+type Span struct {
+	Event
+	Children []*Span
+}
+
+// String returns s as an indented tree, with one line for each Span, such as
+//
+//	write A: 1
+//	  binding A -> B: 2
+//	    write B: 2
+func (s *Span) String() string {
+	var b strings.Builder
+	s.format(&b, 0)
+	return b.String()
+}
+
+func (s *Span) format(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(s.Kind.String())
+	b.WriteString(" ")
+	b.WriteString(s.Source)
+	if s.Target != "" {
+		b.WriteString(" -> ")
+		b.WriteString(s.Target)
+	}
+	fmt.Fprintf(b, ": %v\n", s.Value)
+	for _,c := range s.Children {
+		c.format(b, depth+1)
+	}
+}
+
+// Find returns the first Span in the tree rooted at s, in depth-first order,
+// for which f returns true, or nil if there is none.
+func (s *Span) Find(f func(*Span) bool) *Span {
+	if f(s) {
+		return s
+	}
+	for _,c := range s.Children {
+		if found := c.Find(f); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// TraceRecorder is a Tracer that keeps the Events of recent traces, so that
+// they can be inspected as trees of Spans. A TraceRecorder must be created
+// with NewTraceRecorder. It is safe to use from multiple goroutines.
+type TraceRecorder struct {
+	lock sync.Mutex
+	max int
+	traces map[uint64][]Event
+	order []uint64 // trace IDs, oldest first
+}
+
+// NewTraceRecorder returns a TraceRecorder that keeps the Events of the max
+// most recent traces. If max is not positive, every trace is kept.
+func NewTraceRecorder(max int) *TraceRecorder {
+	return &TraceRecorder{max: max, traces: make(map[uint64][]Event)}
+}
+
+// Trace records e.
+func (r *TraceRecorder) Trace(e Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _,ok := r.traces[e.TraceID]; !ok {
+		r.order = append(r.order, e.TraceID)
+		if r.max > 0 && len(r.order) > r.max {
+			delete(r.traces, r.order[0])
+			r.order = r.order[1:]
+		}
+	}
+	r.traces[e.TraceID] = append(r.traces[e.TraceID], e)
+}
+
+// Traces returns the IDs of the traces kept by r, oldest first.
+func (r *TraceRecorder) Traces() []uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]uint64(nil), r.order...)
+}
+
+// Tree returns the Span that started the trace with ID trace, with every
+// Span it caused beneath it, in the order they started. Tree returns nil if
+// the trace is not kept by r, or if the event that started it has not
+// finished yet.
+func (r *TraceRecorder) Tree(trace uint64) *Span {
+	r.lock.Lock()
+		events := append([]Event(nil), r.traces[trace]...)
+	r.lock.Unlock()
+
+	spans := make(map[uint64]*Span, len(events))
+	for _,e := range events {
+		spans[e.SpanID] = &Span{Event: e}
+	}
+	root := spans[trace]
+	if root == nil {
+		return nil
+	}
+	for _,s := range spans {
+		if p,ok := spans[s.ParentID]; ok && s != root {
+			p.Children = append(p.Children, s)
+		}
+	}
+	for _,s := range spans {
+		sort.Slice(s.Children, func(i, j int) bool {
+			return s.Children[i].SpanID < s.Children[j].SpanID
+		})
+	}
+	return root
+}
+
+// Cause returns the tree of the most recent trace kept by r that wrote to the
+// Initiator named name, whether by a write, index write or key write, as
+// returned by Tree. Cause returns nil if there is no such trace.
+func (r *TraceRecorder) Cause(name string) *Span {
+	r.lock.Lock()
+		var found uint64
+		for n := len(r.order)-1; n >= 0 && found == 0; n-- {
+			for _,e := range r.traces[r.order[n]] {
+				if e.Source == name && isWrite(e.Kind) {
+					found = r.order[n]
+					break
+				}
+			}
+		}
+	r.lock.Unlock()
+	if found == 0 {
+		return nil
+	}
+	return r.Tree(found)
+}
+
+func isWrite(k EventKind) bool {
+	return k == WriteEvent || k == IndexWriteEvent || k == KeyWriteEvent
+}
@@ -0,0 +1,129 @@
+package reactor
+
+import (
+	"testing"
+	"time"
+)
+
+// returns a TraceRecorder set as the global Tracer for the rest of the test.
+func recordTraces(t *testing.T) *TraceRecorder {
+	r := NewTraceRecorder(0)
+	SetTracer(r)
+	t.Cleanup(func() {SetTracer(nil)})
+	return r
+}
+
+func TestTraceTreeBindingChain(t *testing.T) {
+	r := recordTraces(t)
+	var a Trigger
+	var b, c Indicator
+	a.SetName("A")
+	b.SetName("B")
+	c.SetName("C")
+	b.AddBinding(&a, func(v interface{}) interface{} {return v.(int)+1})
+	c.AddBinding(&b, func(v interface{}) interface{} {return v.(int)*10})
+
+	a.SetValue(1)
+
+	tree := r.Cause("C")
+	if tree == nil {
+		t.Fatal("No trace found for C")
+	}
+	want := "write A: 1\n" +
+	        "  binding A -> B: 2\n" +
+	        "    write B: 2\n" +
+	        "      binding B -> C: 20\n" +
+	        "        write C: 20\n"
+	if got := tree.String(); got != want {
+		t.Fatalf("Expected tree\n%s\ngot\n%s", want, got)
+	}
+	if tree.ParentID != 0 || tree.TraceID != tree.SpanID {
+		t.Fatalf("Expected the root to start the trace; got %+v", tree.Event)
+	}
+}
+
+func TestTraceSeparateWaves(t *testing.T) {
+	r := recordTraces(t)
+	var a Trigger
+	a.SetValue(1)
+	a.SetValue(2)
+
+	if got := r.Traces(); len(got) != 2 {
+		t.Fatalf("Expected each write to start a trace; got %v", got)
+	}
+}
+
+func TestTraceTreeConcurrentBinding(t *testing.T) {
+	r := recordTraces(t)
+	var a Trigger
+	var b Indicator
+	a.SetName("A")
+	b.SetName("B")
+	b.AddConcurrentBinding(&a, TrivialBinding)
+
+	a.SetValue(4)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if tree := r.Cause("B"); tree != nil {
+			write := tree.Find(func(s *Span) bool {
+				return s.Kind == WriteEvent && s.Source == "B"
+			})
+			queued := tree.Find(func(s *Span) bool {
+				return s.Kind == ConcurrentBindingEvent
+			})
+			if write != nil && queued != nil {
+				if tree.Source != "A" || len(queued.Children) != 1 || queued.Children[0] != write {
+					t.Fatalf("Unexpected tree\n%s", tree)
+				}
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("No complete trace of the concurrent binding; got %v", r.Traces())
+}
+
+func TestTraceTreeDelayedBinding(t *testing.T) {
+	r := recordTraces(t)
+	var a Trigger
+	var d Indicator
+	a.SetName("A")
+	d.SetName("D")
+	d.AddDelayedBinding(&a, TrivialBinding)
+	a.SetValue(3)
+
+	d.Value()
+
+	tree := r.Cause("D")
+	if tree == nil {
+		t.Fatal("No trace found for D")
+	}
+	want := "read D: 3\n" +
+	        "  delayed-binding D -> A: 3\n" +
+	        "    read A: 3\n" +
+	        "  write D: 3\n"
+	if got := tree.String(); got != want {
+		t.Fatalf("Expected tree\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestTraceRecorderLimit(t *testing.T) {
+	r := NewTraceRecorder(2)
+	var a Trigger
+	a.SetTracer(r)
+	for n := 0; n < 5; n++ {
+		a.SetValue(n)
+	}
+
+	traces := r.Traces()
+	if len(traces) != 2 {
+		t.Fatalf("Expected 2 traces to be kept; got %v", traces)
+	}
+	if tree := r.Tree(traces[1]); tree == nil || tree.Value != 4 {
+		t.Fatalf("Expected the last trace to be kept; got %v", tree)
+	}
+	if r.Cause("missing") != nil {
+		t.Fatal("Expected no trace for an unknown name")
+	}
+}
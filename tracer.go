@@ -0,0 +1,474 @@
+package reactor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventKind identifies the kind of an Event.
+type EventKind int
+
+// The kinds of Event that are traced.
+const (
+	// ReadEvent is the reading of a whole value, such as by Value.
+	ReadEvent EventKind = iota
+	// WriteEvent is the propagation of a write to a whole value, such as by
+	// SetValue, to its callbacks, watchers and bindings.
+	WriteEvent
+	// IndexReadEvent and IndexWriteEvent are reads and writes of a single
+	// element of a slice.
+	IndexReadEvent
+	IndexWriteEvent
+	// KeyReadEvent and KeyWriteEvent are reads and writes of a single key of
+	// a map.
+	KeyReadEvent
+	KeyWriteEvent
+	// BindingEvent is the evaluation of a binding, and the setting of its
+	// Binder, when the Initiator it is bound to changes.
+	BindingEvent
+	// DelayedBindingEvent is the evaluation of a delayed binding when its
+	// Binder is read.
+	DelayedBindingEvent
+	// ConcurrentBindingEvent is the evaluation of a queued concurrent
+	// binding, and the setting of its Binder.
+	ConcurrentBindingEvent
+)
+
+var eventKindNames = [...]string{
+	"read",
+	"write",
+	"index-read",
+	"index-write",
+	"key-read",
+	"key-write",
+	"binding",
+	"delayed-binding",
+	"concurrent-binding",
+}
+
+func (k EventKind) String() string {
+	if k < 0 || int(k) >= len(eventKindNames) {
+		return "unknown"
+	}
+	return eventKindNames[k]
+}
+
+// Event describes a single event traced by a Tracer.
+//
+// Source is the name of the Initiator the event happened to, or "" if it
+// has not been named. For binding events, Target is the name of the other
+// Initiator involved: the Binder that was set by a BindingEvent, or the
+// Initiator that was read by a DelayedBindingEvent. ConcurrentBindingEvents
+// are traced by the Binder that was set, once its queued binding runs, when
+// the Initiator it is bound to is no longer known, so their Target is "".
+//
+// For writes, Prev and Value are the previous and new values, as passed to
+// write callbacks. For reads, Prev is nil. For binding events, Prev is the
+// value passed to the BindingFunc, and Value is its result.
+//
+// SourceID and TargetID identify the same Initiators as Source and Target,
+// by a number that is unique within the process, so Initiators that have not
+// been named can still be told apart. A TargetID of 0 means there is no
+// Target, or it is not known.
+//
+// Duration is the time taken to run the callbacks, watchers and bindings of
+// the event, including any events they caused. For binding events, it is the
+// time taken to evaluate the binding and set its Binder. Self is the part of
+// Duration not spent in the events it caused on the same goroutine, which is
+// the time taken by the callbacks, watchers and BindingFunc themselves.
+//
+// Every Event is a span of a trace. SpanID identifies the event, and ParentID
+// is the SpanID of the event that caused it, such as the write that ran a
+// binding, or the binding that set a Binder. An event with no cause starts a
+// new trace, and has a ParentID of 0. TraceID is the SpanID of the event that
+// started the trace, so every event of a single propagation wave, including
+// delayed and concurrent bindings, shares its TraceID. The Events of a trace
+// can be assembled into a tree with a TraceRecorder.
+type Event struct {
+	Time time.Time
+	Source string
+	Target string
+	SourceID, TargetID uint64
+	Kind EventKind
+	Prev, Value interface{}
+	Duration, Self time.Duration
+	TraceID, SpanID, ParentID uint64
+}
+
+// Tracer receives the Events of the Initiators it traces. Trace is called
+// synchronously, once each event has finished, on the goroutine that caused
+// the event, so an event caused by another is traced before it. Trace must
+// not read or write the Initiator that caused the event.
+type Tracer interface {
+	Trace(Event)
+}
+
+// MultiTracer returns a Tracer that passes every Event to each of tracers,
+// in order, so that several Tracers can be set with SetTracer. Nil Tracers
+// are ignored, and if every Tracer is nil, MultiTracer returns nil.
+func MultiTracer(tracers ...Tracer) Tracer {
+	m := make(multiTracer, 0, len(tracers))
+	for _,t := range tracers {
+		if t != nil {
+			m = append(m, t)
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// TracerFunc is a function that implements Tracer.
+type TracerFunc func(Event)
+
+// Trace calls f(e).
+func (f TracerFunc) Trace(e Event) {
+	f(e)
+}
+
+// tracerBox allows a nil Tracer to be stored in an atomic.Value.
+type tracerBox struct {
+	t Tracer
+}
+
+var globalTracer atomic.Value
+
+// SetTracer sets the Tracer used by every Initiator that does not have a
+// Tracer of its own. A nil Tracer disables tracing, which is the default.
+func SetTracer(t Tracer) {
+	globalTracer.Store(tracerBox{t})
+}
+
+func currentTracer() Tracer {
+	b,_ := globalTracer.Load().(tracerBox)
+	return b.t
+}
+
+// Probe holds the name and Tracer of an Initiator, and is embedded in every
+// Initiator in this module. The zero value is an unnamed Probe that uses the
+// global Tracer set with SetTracer.
+type Probe struct {
+	lock sync.Mutex
+	name string
+	id uint64 // assigned on first use
+	tracer Tracer
+	extra []Tracer // added with AddTracer
+	spans []spanRef // events of p in progress, innermost last
+	slow time.Duration // Watchdog threshold, if set
+}
+
+// SetName sets the name that identifies p in traced Events.
+func (p *Probe) SetName(name string) {
+	p.lock.Lock()
+		p.name = name
+	p.lock.Unlock()
+}
+
+// Name returns the name set with SetName, or "" if p has not been named.
+func (p *Probe) Name() string {
+	p.lock.Lock()
+		name := p.name
+	p.lock.Unlock()
+	return name
+}
+
+// SetTracer sets the Tracer that receives the Events of p, in place of the
+// global Tracer. If t is nil, the global Tracer is used again.
+func (p *Probe) SetTracer(t Tracer) {
+	p.lock.Lock()
+		p.tracer = t
+	p.lock.Unlock()
+}
+
+// AddTracer adds t to receive the Events of p, in addition to the Tracer set
+// with SetTracer, or the global Tracer. Tracers added to p cannot be removed,
+// and are called in the order they were added, after the other Tracer.
+func (p *Probe) AddTracer(t Tracer) {
+	p.lock.Lock()
+		p.extra = append(p.extra, t)
+	p.lock.Unlock()
+}
+
+func (p *Probe) probe() *Probe {
+	return p
+}
+
+var probeSeq uint64
+
+// returns the number that identifies p in traced Events, assigning one if p
+// does not have one yet.
+func (p *Probe) ident() uint64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.id == 0 {
+		p.id = atomic.AddUint64(&probeSeq, 1)
+	}
+	return p.id
+}
+
+// returns the Tracer of p, falling back on the global Tracer, followed by any
+// Tracers added with AddTracer.
+func (p *Probe) current() Tracer {
+	p.lock.Lock()
+		t := p.tracer
+		extra := p.extra
+	p.lock.Unlock()
+	if t == nil {
+		t = currentTracer()
+	}
+	if len(extra) == 0 {
+		return t
+	}
+	if t == nil {
+		return multiTracer(extra)
+	}
+	return multiTracer(append([]Tracer{t}, extra...))
+}
+
+// multiTracer is a Tracer that passes every Event to each of its Tracers, in
+// order.
+type multiTracer []Tracer
+
+func (m multiTracer) Trace(e Event) {
+	for _,t := range m {
+		t.Trace(e)
+	}
+}
+
+// probed is implemented by every type that embeds a Probe.
+type probed interface {
+	probe() *Probe
+}
+
+// returns the name of x, if it embeds a Probe.
+func nameOf(x interface{}) string {
+	if p,ok := x.(probed); ok {
+		return p.probe().Name()
+	}
+	return ""
+}
+
+// returns the number that identifies x in traced Events, if it embeds a Probe,
+// or 0 otherwise.
+func idOf(x interface{}) uint64 {
+	if p,ok := x.(probed); ok {
+		return p.probe().ident()
+	}
+	return 0
+}
+
+// spanRef identifies a span of a trace. The zero spanRef is no span.
+type spanRef struct {
+	trace, id uint64
+}
+
+var spanSeq uint64
+
+// nestedLock guards nested, which holds the time spent so far in the events
+// caused by each span in progress, keyed by its id.
+var nestedLock sync.Mutex
+var nested map[uint64]time.Duration
+
+// causeLock guards causes, which holds the span about to write to each Probe
+// that is the target of a binding. A Probe's next event is caused by that span,
+// rather than by whatever the Probe is already doing.
+var causeLock sync.Mutex
+var causes map[*Probe]spanRef
+
+// returns the pending cause of p, and removes it.
+func takeCause(p *Probe) spanRef {
+	causeLock.Lock()
+	defer causeLock.Unlock()
+	s,ok := causes[p]
+	if ok {
+		delete(causes, p)
+	}
+	return s
+}
+
+// returns the pending cause of x, if x embeds a Probe, without removing it.
+func causeOf(x interface{}) spanRef {
+	p,ok := x.(probed)
+	if !ok {
+		return spanRef{}
+	}
+	causeLock.Lock()
+	defer causeLock.Unlock()
+	return causes[p.probe()]
+}
+
+// runs f with s as the pending cause of x, if x embeds a Probe. Any cause left
+// unused by f, such as by a write held back by a Batch, is discarded.
+func withCause(x interface{}, s spanRef, f func()) {
+	p,ok := x.(probed)
+	if !ok || s.id == 0 {
+		f()
+		return
+	}
+	target := p.probe()
+	causeLock.Lock()
+		if causes == nil {
+			causes = make(map[*Probe]spanRef)
+		}
+		causes[target] = s
+	causeLock.Unlock()
+
+	defer func() {
+		causeLock.Lock()
+			if causes[target] == s {
+				delete(causes, target)
+			}
+		causeLock.Unlock()
+	}()
+	f()
+}
+
+// EventStart marks the start of an event, as returned by StartEvent.
+type EventStart struct {
+	time time.Time
+	span, parent spanRef
+}
+
+// StartEvent starts an event of p, to be passed to EmitEvent once it has
+// finished. The event is caused by the binding about to set p, if there is
+// one, or otherwise by the innermost event of p that is still in progress. If
+// p is not being traced, nothing is recorded, and the clock is not read.
+//
+// Events are attributed to their causes as they happen, so events of the same
+// Initiator running on several goroutines at once may be attributed to the
+// wrong parent, and an Initiator that is not traced breaks the chain of any
+// trace passing through it. Writes held back by a Batch start new traces when
+// the Batch ends.
+//
+// StartEvent is intended for use in implementing Initiators outside of this
+// package, and its use is discouraged otherwise.
+func StartEvent(p *Probe) EventStart {
+	if p.current() == nil {
+		return EventStart{}
+	}
+	return startSpan(p, takeCause(p))
+}
+
+// starts a span of p caused by parent, or by the innermost span of p in
+// progress if parent is the zero spanRef.
+func startSpan(p *Probe, parent spanRef) EventStart {
+	id := atomic.AddUint64(&spanSeq, 1)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if parent.id == 0 && len(p.spans) > 0 {
+		parent = p.spans[len(p.spans)-1]
+	}
+	s := EventStart{time: time.Now(), span: spanRef{parent.trace, id}, parent: parent}
+	if parent.id == 0 {
+		s.span.trace = id
+	}
+	p.spans = append(p.spans, s.span)
+
+	nestedLock.Lock()
+		if nested == nil {
+			nested = make(map[uint64]time.Duration)
+		}
+		nested[id] = 0
+	nestedLock.Unlock()
+	return s
+}
+
+// ends the span of s, which took d, and returns the part of d not spent in
+// the events it caused. Unless the span is a ConcurrentBindingEvent, which
+// runs apart from its cause, d is added to the time spent in the events
+// caused by its parent, if the parent is still in progress.
+func endNested(s EventStart, kind EventKind, d time.Duration) time.Duration {
+	nestedLock.Lock()
+	defer nestedLock.Unlock()
+	self := d - nested[s.span.id]
+	delete(nested, s.span.id)
+	if _,ok := nested[s.parent.id]; ok && kind != ConcurrentBindingEvent {
+		nested[s.parent.id] += d
+	}
+	if self < 0 {
+		return 0
+	}
+	return self
+}
+
+// removes s from the spans of p in progress.
+func (p *Probe) endSpan(s spanRef) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for n := len(p.spans)-1; n >= 0; n-- {
+		if p.spans[n] == s {
+			p.spans = append(p.spans[:n], p.spans[n+1:]...)
+			return
+		}
+	}
+}
+
+// EmitEvent ends the event started by start, and passes e to the Tracer of
+// p, if there is one, with its Time, Duration, Self, Source, SourceID and
+// span identifiers filled in. If the event was not started while p was being traced, it is
+// treated as starting now, as a trace of its own.
+//
+// EmitEvent is intended for use in implementing Initiators outside of this
+// package, and its use is discouraged otherwise.
+func EmitEvent(p *Probe, e Event, start EventStart) {
+	var d, self time.Duration
+	if start.span.id != 0 {
+		p.endSpan(start.span)
+		d = time.Since(start.time)
+		self = endNested(start, e.Kind, d)
+	}
+	t := p.current()
+	if t == nil {
+		return
+	}
+	if start.span.id == 0 {
+		id := atomic.AddUint64(&spanSeq, 1)
+		e.Time = time.Now()
+		e.TraceID, e.SpanID = id, id
+	} else {
+		e.Time = start.time
+		e.Duration, e.Self = d, self
+		e.TraceID, e.SpanID, e.ParentID = start.span.trace, start.span.id, start.parent.id
+	}
+	e.Source, e.SourceID = p.Name(), p.ident()
+	t.Trace(e)
+}
+
+// RunBinding evaluates b with v, setting b.Binder to the result unless b is
+// concurrent or the result is Unchanged or a failure returned by a
+// FallibleBinding, and traces it as a BindingEvent of p. The events of b.Binder
+// caused by the binding, including those of a concurrent binding once it has
+// been queued and run, are traced as its children.
+//
+// RunBinding is intended for use in implementing Initiators outside of this
+// package, and its use is discouraged otherwise.
+func RunBinding(p *Probe, b Binding, v interface{}) {
+	start := StartEvent(p)
+	var val interface{}
+	withCause(b.Binder, start.span, func() {
+		val = b.F(v)
+		if !b.Concurrent {
+			Settle(b.Binder, val)
+		}
+	})
+	EmitEvent(p, Event{Kind: BindingEvent, Target: nameOf(b.Binder), TargetID: idOf(b.Binder), Prev: v, Value: val}, start)
+}
+
+// RunDelayed evaluates the delayed binding b with the current value of its
+// Source, traces it as a DelayedBindingEvent of p, and returns the result.
+// Reading the Source is traced as a child of the binding.
+//
+// RunDelayed is intended for use in implementing Binders outside of this
+// package, and its use is discouraged otherwise.
+func RunDelayed(p *Probe, b Binding) interface{} {
+	start := StartEvent(p)
+	var in interface{}
+	withCause(b.Source, start.span, func() {
+		in = b.Source.Value()
+	})
+	v := b.F(in)
+	EmitEvent(p, Event{Kind: DelayedBindingEvent, Target: nameOf(b.Source), TargetID: idOf(b.Source), Prev: in, Value: v}, start)
+	return v
+}
@@ -0,0 +1,230 @@
+package reactor
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventLog is a Tracer that records every Event it receives.
+type eventLog struct {
+	lock sync.Mutex
+	events []Event
+}
+
+func (l *eventLog) Trace(e Event) {
+	l.lock.Lock()
+		l.events = append(l.events, e)
+	l.lock.Unlock()
+}
+
+func (l *eventLog) get() []Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]Event(nil), l.events...)
+}
+
+func TestEventKindString(t *testing.T) {
+	if got := KeyWriteEvent.String(); got != "key-write" {
+		t.Fatalf("KeyWriteEvent.String() = %q; want %q", got, "key-write")
+	}
+	if got := EventKind(100).String(); got != "unknown" {
+		t.Fatalf("EventKind(100).String() = %q; want %q", got, "unknown")
+	}
+}
+
+func TestTraceWriteAndBinding(t *testing.T) {
+	var log eventLog
+	var trigger Trigger
+	var ind Indicator
+	trigger.SetName("source")
+	ind.SetName("derived")
+	trigger.SetTracer(&log)
+
+	ind.AddBinding(&trigger, func(v interface{}) interface{} {return v.(int)*2})
+	trigger.SetValue(3)
+
+	events := log.get()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events; got %v", events)
+	}
+
+	b := events[0]
+	if b.Kind != BindingEvent || b.Source != "source" || b.Target != "derived" || b.Prev != 3 || b.Value != 6 {
+		t.Fatalf("Unexpected binding event %+v", b)
+	}
+	w := events[1]
+	if w.Kind != WriteEvent || w.Source != "source" || w.Prev != nil || w.Value != 3 {
+		t.Fatalf("Unexpected write event %+v", w)
+	}
+	if w.Time.IsZero() || w.Duration < b.Duration {
+		t.Fatalf("Expected write to start first and include its binding; got %+v and %+v", w, b)
+	}
+}
+
+func TestTraceRead(t *testing.T) {
+	var log eventLog
+	var trigger Trigger
+	trigger.SetTracer(&log)
+	trigger.SetValue(1)
+	trigger.Value()
+
+	events := log.get()
+	if len(events) != 2 || events[1].Kind != ReadEvent || events[1].Value != 1 {
+		t.Fatalf("Expected a write and a read event; got %v", events)
+	}
+}
+
+func TestTraceDelayedBinding(t *testing.T) {
+	var log eventLog
+	var trigger Trigger
+	var ind Indicator
+	trigger.SetName("source")
+	ind.SetTracer(&log)
+
+	ind.AddDelayedBinding(&trigger, TrivialBinding)
+	trigger.SetValue(5)
+	ind.Value()
+
+	events := log.get()
+	if len(events) == 0 {
+		t.Fatal("No events traced")
+	}
+	d := events[0]
+	if d.Kind != DelayedBindingEvent || d.Target != "source" || d.Value != 5 {
+		t.Fatalf("Unexpected delayed binding event %+v", d)
+	}
+	if last := events[len(events)-1]; last.Kind != ReadEvent {
+		t.Fatalf("Expected the read to be traced last; got %+v", last)
+	}
+}
+
+func TestTraceConcurrentBinding(t *testing.T) {
+	var log eventLog
+	var trigger Trigger
+	var ind Indicator
+	ind.SetName("derived")
+	ind.SetTracer(&log)
+	done := make(chan struct{})
+	ind.AddWriteCallback(func(prev, v interface{}) {
+		close(done)
+	})
+
+	ind.AddConcurrentBinding(&trigger, TrivialBinding)
+	trigger.SetValue(7)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Concurrent binding did not run")
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _,e := range log.get() {
+			if e.Kind == ConcurrentBindingEvent {
+				if e.Source != "derived" || e.Prev != 7 || e.Value != 7 {
+					t.Fatalf("Unexpected concurrent binding event %+v", e)
+				}
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("No concurrent binding event traced; got %v", log.get())
+}
+
+func TestGlobalTracer(t *testing.T) {
+	var global, local eventLog
+	SetTracer(&global)
+	defer SetTracer(nil)
+
+	var a, b Trigger
+	b.SetTracer(&local)
+	a.SetValue(1)
+	b.SetValue(2)
+
+	if got := global.get(); len(got) != 1 || got[0].Value != 1 {
+		t.Fatalf("Expected the global tracer to receive only a's write; got %v", got)
+	}
+	if got := local.get(); len(got) != 1 || got[0].Value != 2 {
+		t.Fatalf("Expected b's own tracer to receive its write; got %v", got)
+	}
+
+	SetTracer(nil)
+	a.SetValue(3)
+	if got := global.get(); len(got) != 1 {
+		t.Fatalf("Expected no events once tracing is disabled; got %v", got)
+	}
+}
+
+func TestProbeAddTracer(t *testing.T) {
+	var global, added eventLog
+	SetTracer(&global)
+	defer SetTracer(nil)
+
+	var trigger Trigger
+	trigger.AddTracer(&added)
+	trigger.SetValue(1)
+	if len(global.get()) != 1 || len(added.get()) != 1 {
+		t.Fatalf("Expected both tracers to receive the write; got %v and %v", global.get(), added.get())
+	}
+
+	SetTracer(nil)
+	trigger.SetValue(2)
+	if len(global.get()) != 1 || len(added.get()) != 2 {
+		t.Fatalf("Expected only the added tracer to receive the write; got %v and %v", global.get(), added.get())
+	}
+}
+
+func TestMultiTracer(t *testing.T) {
+	var a, b eventLog
+	var trigger Trigger
+	trigger.SetTracer(MultiTracer(&a, nil, &b))
+	trigger.SetValue(1)
+	if len(a.get()) != 1 || len(b.get()) != 1 {
+		t.Fatalf("Expected both tracers to receive the write; got %v and %v", a.get(), b.get())
+	}
+	if MultiTracer(nil) != nil {
+		t.Fatal("Expected MultiTracer of only nil Tracers to be nil")
+	}
+}
+
+func TestTraceIDsAndSelf(t *testing.T) {
+	var log eventLog
+	var a, b Trigger
+	var ind Indicator
+	a.SetTracer(&log)
+	b.SetTracer(&log)
+	ind.SetTracer(&log)
+	ind.AddBinding(&a, TrivialBinding)
+	ind.AddWriteCallback(func(prev, v interface{}) {
+		time.Sleep(10*time.Millisecond)
+	})
+	a.SetValue(1)
+	b.SetValue(2)
+
+	got := log.get()
+	if len(got) != 4 {
+		t.Fatalf("Expected 4 events; got %v", got)
+	}
+	indWrite, binding, aWrite, bWrite := got[0], got[1], got[2], got[3]
+	if aWrite.SourceID == 0 || aWrite.SourceID == bWrite.SourceID {
+		t.Fatalf("Expected distinct IDs for unnamed Initiators; got %d and %d", aWrite.SourceID, bWrite.SourceID)
+	}
+	if binding.SourceID != aWrite.SourceID || binding.TargetID != indWrite.SourceID {
+		t.Fatalf("Expected binding from %d to %d; got %+v", aWrite.SourceID, indWrite.SourceID, binding)
+	}
+	if indWrite.Self < 10*time.Millisecond || indWrite.Self != indWrite.Duration {
+		t.Fatalf("Expected the callback to take the whole of its write; got %v of %v", indWrite.Self, indWrite.Duration)
+	}
+	if aWrite.Duration < 10*time.Millisecond || aWrite.Self >= 10*time.Millisecond {
+		t.Fatalf("Expected the binding to be excluded from Self; got %v of %v", aWrite.Self, aWrite.Duration)
+	}
+}
+
+func TestStartEventUntraced(t *testing.T) {
+	var p Probe
+	if StartEvent(&p) != (EventStart{}) {
+		t.Fatal("Expected nothing to be started without a tracer")
+	}
+}
@@ -0,0 +1,141 @@
+package reactor
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// ErrConflict is the error returned by Transaction when a transaction could
+// not be committed within TransactionRetries attempts, because other writers
+// kept changing the Triggers it read.
+var ErrConflict = errors.New("reactor: transaction conflicted with concurrent writes")
+
+// TransactionRetries is the maximum number of times Transaction runs its
+// function before giving up with ErrConflict.
+var TransactionRetries = 100
+
+// txWrite is a value written in a transaction, along with the value it
+// replaced once committed.
+type txWrite struct {
+	t *Trigger
+	prev, value interface{}
+}
+
+// Tx is a transaction in progress, used to read and write Triggers within a
+// call to Transaction. Values written using a Tx are not visible outside of
+// the transaction until it is committed. A Tx must not be used outside of the
+// function it was passed to.
+type Tx struct {
+	reads map[*Trigger]uint64
+	writes map[*Trigger]*txWrite
+	order []*txWrite
+}
+
+// Get returns the value of t as seen by tx. If t has been written by tx, that
+// value is returned. Otherwise, the committed value of t is returned, and the
+// transaction will only commit if t is not changed by another writer in the
+// meantime.
+//
+// Get does not run any read callbacks or delayed bindings, since the function
+// passed to Transaction may be run several times.
+func (tx *Tx) Get(t *Trigger) interface{} {
+	if w,ok := tx.writes[t]; ok {
+		return w.value
+	}
+
+	t.Lock.Lock()
+		v := t.value
+		version := t.version
+	t.Lock.Unlock()
+
+	if _,ok := tx.reads[t]; !ok {
+		tx.reads[t] = version
+	}
+	return v
+}
+
+// Set sets the value of t to v within tx. The value of t is not changed, and
+// no callbacks or bindings are run, until the transaction is committed.
+func (tx *Tx) Set(t *Trigger, v interface{}) {
+	if w,ok := tx.writes[t]; ok {
+		w.value = v
+		return
+	}
+	w := &txWrite{t: t, value: v}
+	tx.writes[t] = w
+	tx.order = append(tx.order, w)
+}
+
+// commit attempts to apply the writes of tx, reporting whether it succeeded.
+// All Triggers involved are locked in a consistent order, so that concurrent
+// transactions cannot deadlock.
+func (tx *Tx) commit() bool {
+	var triggers []*Trigger
+	for t := range tx.reads {
+		triggers = append(triggers, t)
+	}
+	for t := range tx.writes {
+		if _,ok := tx.reads[t]; !ok {
+			triggers = append(triggers, t)
+		}
+	}
+	sort.Slice(triggers, func(i, j int) bool {
+		return reflect.ValueOf(triggers[i]).Pointer() < reflect.ValueOf(triggers[j]).Pointer()
+	})
+
+	for _,t := range triggers {
+		t.Lock.Lock()
+	}
+	defer func() {
+		for _,t := range triggers {
+			t.Lock.Unlock()
+		}
+	}()
+
+	for t,version := range tx.reads {
+		if t.version != version {
+			return false
+		}
+	}
+	for _,w := range tx.order {
+		w.prev = w.t.value
+		w.t.value = w.value
+		w.t.version++
+		w.t.samples.add(w.value)
+	}
+	return true
+}
+
+// Transaction runs f, reading and writing Triggers through the Tx passed to
+// it, and atomically commits every value written if f returns nil. If another
+// writer changed a Trigger read by f before the transaction could commit, f
+// is run again with a new Tx, up to TransactionRetries times, after which
+// ErrConflict is returned.
+//
+// If f returns an error, nothing written by f is applied, and that error is
+// returned. Since f may be run more than once, it should not have side effects
+// other than through the Tx.
+//
+// Write callbacks and bindings are only run for committed values, once the
+// transaction has been committed. They are run as if by Batch, so each
+// written Trigger propagates its change once.
+func Transaction(f func(*Tx) error) error {
+	for i:=0; i<TransactionRetries; i++ {
+		tx := &Tx{reads: make(map[*Trigger]uint64), writes: make(map[*Trigger]*txWrite)}
+		if err := f(tx); err != nil {
+			return err
+		}
+		if !tx.commit() {
+			continue
+		}
+
+		Batch(func() {
+			for _,w := range tx.order {
+				Batched(w.t, w.prev, w.value, w.t.propagate)
+			}
+		})
+		return nil
+	}
+	return ErrConflict
+}
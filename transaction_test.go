@@ -0,0 +1,116 @@
+package reactor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	var from,to Trigger
+	var written []interface{}
+
+	from.SetValue(10)
+	to.SetValue(0)
+	to.AddWriteCallback(func(prev, v interface{}) {
+		written = append(written, v)
+	})
+
+	err := Transaction(func(tx *Tx) error {
+		amount := 4
+		tx.Set(&from, tx.Get(&from).(int) - amount)
+		tx.Set(&to, tx.Get(&to).(int) + amount)
+		tx.Set(&to, tx.Get(&to).(int) * 2)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected commit; got error: %v", err)
+	}
+	if from.Value() != 6 || to.Value() != 8 {
+		t.Fatalf("Expected (6, 8); got (%v, %v)", from.Value(), to.Value())
+	}
+	if len(written) != 1 || written[0] != 8 {
+		t.Fatalf("Expected a single write callback for 8; got %v", written)
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	var trigger Trigger
+	var count int
+	fail := errors.New("insufficient funds")
+
+	trigger.SetValue(1)
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		count += 1
+	})
+
+	err := Transaction(func(tx *Tx) error {
+		tx.Set(&trigger, 100)
+		if tx.Get(&trigger) != 100 {
+			t.Fatalf("Transaction should see its own writes; got %v", tx.Get(&trigger))
+		}
+		return fail
+	})
+
+	if err != fail {
+		t.Fatalf("Expected error %v; got %v", fail, err)
+	}
+	if trigger.Value() != 1 || count != 0 {
+		t.Fatalf("Expected value 1 and no callbacks after rollback; got %v and %d callbacks", trigger.Value(), count)
+	}
+}
+
+func TestTransactionConflict(t *testing.T) {
+	var t1,t2 Trigger
+	var wg sync.WaitGroup
+	t1.SetValue(1000)
+	t2.SetValue(0)
+
+	workers, iters := 8, 100
+	for i:=0; i<workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j:=0; j<iters; j++ {
+				err := Transaction(func(tx *Tx) error {
+					tx.Set(&t1, tx.Get(&t1).(int) - 1)
+					tx.Set(&t2, tx.Get(&t2).(int) + 1)
+					return nil
+				})
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if t1.Value() != 1000 - workers*iters || t2.Value() != workers*iters {
+		t.Fatalf("Lost updates: got (%v, %v); want (%d, %d)", t1.Value(), t2.Value(),
+				 1000 - workers*iters, workers*iters)
+	}
+}
+
+func TestTransactionRetry(t *testing.T) {
+	var trigger Trigger
+	var runs int
+	trigger.SetValue(0)
+
+	err := Transaction(func(tx *Tx) error {
+		runs += 1
+		v := tx.Get(&trigger).(int)
+		if runs == 1 {
+			trigger.SetValue(10)
+		}
+		tx.Set(&trigger, v + 1)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected commit; got error: %v", err)
+	}
+	if runs != 2 || trigger.Value() != 11 {
+		t.Fatalf("Expected 2 runs and value 11; got %d runs and value %v", runs, trigger.Value())
+	}
+}
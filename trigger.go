@@ -1,6 +1,7 @@
 package reactor
 
 import (
+	"context"
 	"sync"
 )
 
@@ -9,10 +10,14 @@ import (
 // concurrent callbacks.
 type Trigger struct {
 	Lock sync.Mutex
+	Probe
 	value interface{}
+	version uint64
+	samples samples
 
 	readCallbacks []ReadCallback
 	writeCallbacks []WriteCallback
+	watchers Watchers
 
 	bindings []Binding
 }
@@ -25,15 +30,25 @@ type Trigger struct {
 // The value(s) passed to the callback are as follows, in order: the current 
 // value.
 func (t *Trigger) Value() interface{} {
+	v,_ := t.ValueVersion()
+	return v
+}
+
+// ValueVersion returns the value of t, as if by Value, along with the version 
+// of t when it was read.
+func (t *Trigger) ValueVersion() (interface{}, uint64) {
 	t.Lock.Lock()
 		v := t.value
+		version := t.version
 	t.Lock.Unlock()
 	
+	start := StartEvent(&t.Probe)
 	for _,c := range t.readCallbacks {
 		c(v)
 	}
+	EmitEvent(&t.Probe, Event{Kind: ReadEvent, Value: v}, start)
 
-	return v
+	return v, version
 }
 
 // SetValue sets the value underlying t and runs any callbacks associated 
@@ -41,28 +56,99 @@ func (t *Trigger) Value() interface{} {
 // set yet), the previous value in callbacks will be nil.
 //
 // The value(s) passed to the callback are as follows, in order: the previous 
-// value and the new value.
+// value and the new value. If SetValue is called during a Batch, callbacks and 
+// bindings are held back until the Batch ends.
 func (t *Trigger) SetValue(v interface{}) {
 	t.Lock.Lock()
 		prev := t.value
 		t.value = v
+		t.version++
+		t.samples.add(t.value)
 	t.Lock.Unlock()
 
+	if Batched(t, prev, v, t.propagate) {
+		return
+	}
+	t.propagate(prev, v)
+}
+
+// runs the write callbacks and bindings of t for a change from prev to v.
+func (t *Trigger) propagate(prev, v interface{}) {
+	start := StartEvent(&t.Probe)
 	for _,c := range t.writeCallbacks {
 		c(prev, v)
 	}
 
+	t.watchers.Notify(prev, v)
+
 	for _,b := range t.bindings {
-		val := b.F(v)
-		if !b.Concurrent {
-			b.Binder.SetValue(val)
+		RunBinding(&t.Probe, b, v)
+	}
+	EmitEvent(&t.Probe, Event{Kind: WriteEvent, Prev: prev, Value: v}, start)
+}
+
+// Version returns the version of t, which is increased every time t is 
+// written to.
+func (t *Trigger) Version() uint64 {
+	t.Lock.Lock()
+		version := t.version
+	t.Lock.Unlock()
+	return version
+}
+
+// CompareAndSet sets the value of t to v, as if by SetValue, but only if the 
+// version of t is still version. CompareAndSet reports whether the value was 
+// set. No callbacks are run if it was not.
+func (t *Trigger) CompareAndSet(version uint64, v interface{}) bool {
+	t.Lock.Lock()
+		ok := t.version == version
+		prev := t.value
+		if ok {
+			t.value = v
+			t.version++
+			t.samples.add(t.value)
+		}
+	t.Lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	if !Batched(t, prev, v, t.propagate) {
+		t.propagate(prev, v)
+	}
+	return true
+}
+
+// Update sets the value of t to the result of calling f with the current 
+// value of t, and returns the new value. f is called while holding the lock 
+// on t, so no other write can happen between reading and writing, but f must 
+// not access t itself. Callbacks and bindings are run as if by SetValue, 
+// after the lock is released. No read callbacks are run. If f returns 
+// Unchanged, t is not written, no callbacks are run, and the current value is 
+// returned.
+func (t *Trigger) Update(f func(interface{}) interface{}) interface{} {
+	t.Lock.Lock()
+		prev := t.value
+		v := f(prev)
+		if v == Unchanged {
+			t.Lock.Unlock()
+			return prev
 		}
+		t.value = v
+		t.version++
+		t.samples.add(t.value)
+	t.Lock.Unlock()
+
+	if !Batched(t, prev, v, t.propagate) {
+		t.propagate(prev, v)
 	}
+	return v
 }
 
 // AddBinder adds a Binder to be executed when the value of t changes. If 
 // concurrent is true, b.SetValue will not be called when t changes; instead it
-// will be queued for change.
+// will be queued for change. Otherwise, f may return Unchanged to leave b 
+// unchanged.
 //
 // AddBinder is largely intended for use in implementing Binders, and its 
 // use is heavily discouraged outside of that. If concurrent is true outside 
@@ -70,18 +156,26 @@ func (t *Trigger) SetValue(v interface{}) {
 // If concurrent is false, this will have exactly the same effect as 
 // Binder.AddBinding(), which is the preferred method of creating bindings.
 func (t *Trigger) AddBinder(b Binder, f BindingFunc, concurrent bool) {
-	t.bindings = append(t.bindings, Binding{t, b, f, concurrent})
+	t.bindings = append(t.bindings, Binding{t, b, WatchBinding(&t.Probe, BindingEvent, b, f), concurrent})
+}
+
+// Watch returns a channel that receives a Change for every write to t, 
+// whenever its write callbacks are run, until ctx is cancelled. The channel 
+// is closed once ctx is cancelled. mode determines how changes are handled 
+// when they are made faster than they are received.
+func (t *Trigger) Watch(ctx context.Context, mode WatchMode) <-chan Change {
+	return t.watchers.Add(ctx, mode)
 }
 
 // AddReadCallback adds a callback that will be run when t is read using Value.
 func (t *Trigger) AddReadCallback(r ReadCallback) {
-	t.readCallbacks = append(t.readCallbacks, r)
+	t.readCallbacks = append(t.readCallbacks, WatchRead(&t.Probe, ReadEvent, r))
 }
 
 // AddWriteCallback adds a callback that will be run when t is written to 
 // using SetValue.
 func (t *Trigger) AddWriteCallback(w WriteCallback) {
-	t.writeCallbacks = append(t.writeCallbacks, w)
+	t.writeCallbacks = append(t.writeCallbacks, WatchWrite(&t.Probe, WriteEvent, w))
 }
 
 
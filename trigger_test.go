@@ -2,6 +2,7 @@ package reactor
 
 import (
 	"testing"
+	"sync"
 )
 
 func TestTriggerSetValue(t *testing.T) {
@@ -443,3 +444,77 @@ func TestTriggerCombinedCallbacks(t *testing.T) {
 	}
 }
 
+
+func TestTriggerVersion(t *testing.T) {
+	var trigger Trigger
+
+	if trigger.Version() != 0 {
+		t.Fatalf("Expected initial version 0; got %d", trigger.Version())
+	}
+
+	trigger.SetValue(1)
+	trigger.SetValue(2)
+	if trigger.Version() != 2 {
+		t.Fatalf("Expected version 2 after two writes; got %d", trigger.Version())
+	}
+}
+
+func TestTriggerCompareAndSet(t *testing.T) {
+	var trigger Trigger
+	var count int
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		count += 1
+	})
+
+	version := trigger.Version()
+	if !trigger.CompareAndSet(version, 1) {
+		t.Fatal("CompareAndSet failed with current version")
+	}
+	if trigger.CompareAndSet(version, 2) {
+		t.Fatal("CompareAndSet succeeded with stale version")
+	}
+	if trigger.Value() != 1 || count != 1 {
+		t.Fatalf("Expected value 1 after 1 callback; got %v after %d", trigger.Value(), count)
+	}
+}
+
+func TestTriggerUpdate(t *testing.T) {
+	var trigger Trigger
+	var wg sync.WaitGroup
+	trigger.SetValue(0)
+
+	workers, iters := 8, 100
+	for i:=0; i<workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j:=0; j<iters; j++ {
+				trigger.Update(func(v interface{}) interface{} {
+					return v.(int) + 1
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if trigger.Value() != workers*iters {
+		t.Fatalf("Lost updates: expected %d; got %v", workers*iters, trigger.Value())
+	}
+}
+
+func TestTriggerUpdateUnchanged(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(1)
+	version := trigger.Version()
+	written := 0
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		written++
+	})
+
+	v := trigger.Update(func(v interface{}) interface{} {
+		return Unchanged
+	})
+	if v != 1 || trigger.Value() != 1 || trigger.Version() != version || written != 0 {
+		t.Fatalf("Expected no write; got %v at version %d after %d callbacks", v, trigger.Version(), written)
+	}
+}
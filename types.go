@@ -44,6 +44,11 @@ type ReadCallback func(interface{})
 type WriteCallback func(interface{}, interface{})
 // BindingFunc is the function type used in all bindings
 type BindingFunc func(interface{}) interface{}
+// FallibleBindingFunc is the function type used in bindings that can fail. 
+// If the returned error is non-nil, the returned value is ignored.
+type FallibleBindingFunc func(interface{}) (interface{}, error)
+// ErrorCallback is the function type used in all error callbacks
+type ErrorCallback func(error)
 
 
 // Initiator is the interface that defines the minimum functions required 
@@ -97,6 +102,52 @@ type Binder interface {
 	AddConcurrentBinding(Initiator, BindingFunc)
 }
 
+// FallibleBinder is the interface that defines bindings that may fail to 
+// produce a value. When a fallible binding fails, the FallibleBinder keeps its 
+// previous value and records the error instead.
+//
+// Err returns the error from the most recent failed binding, or nil if the 
+// value of the FallibleBinder has been set since.
+//
+// Fail records err as though a fallible binding had failed. Like AddBinder, 
+// this is largely a convenience method used to propagate errors between 
+// Binders, and its use is discouraged otherwise.
+type FallibleBinder interface {
+	Binder
+	AddFallibleBinding(Initiator, FallibleBindingFunc)
+	Err() error
+	Fail(error)
+}
+
+// Versioned is the interface that defines atomic read-modify-write operations 
+// on an Initiator. Every write to a Versioned Initiator increases its version.
+//
+// Version returns the current version of the Initiator.
+//
+// CompareAndSet sets the value of the Initiator, but only if its version is 
+// still the given version, and reports whether it did so.
+//
+// Update sets the value of the Initiator to the result of calling a function 
+// with its current value, without allowing any other write in between, and 
+// returns the new value.
+type Versioned interface {
+	Initiator
+	Version() uint64
+	CompareAndSet(uint64, interface{}) bool
+	Update(func(interface{}) interface{}) interface{}
+}
+
+// VersionedReader is the interface implemented by Versioned Initiators that 
+// can read their value together with its version.
+//
+// ValueVersion returns the value of the Initiator, as if by Value, along with 
+// the version it had when that value was read. The value and version are read 
+// atomically, so no write can happen between them.
+type VersionedReader interface {
+	Versioned
+	ValueVersion() (interface{}, uint64)
+}
+
 // ReadBinder is the interface that combines ReadInitiator and Binder methods 
 // for convenience.
 type ReadBinder interface {
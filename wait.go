@@ -0,0 +1,52 @@
+package reactor
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotWatchable is the error returned by WaitFor when the Initiator passed
+// to it does not implement Watcher.
+var ErrNotWatchable = errors.New("reactor: Initiator does not implement Watcher")
+
+// waitBuffer is the number of changes queued for WaitFor and similar 
+// functions while they are evaluating their predicate.
+const waitBuffer = 16
+
+// WaitFor blocks until the value of i satisfies pred, and returns that value.
+// The current value of i is checked first, as if by Value. After that, pred 
+// is called with the new value of every write to i, in order, until one 
+// satisfies it. If ctx is cancelled or times out first, WaitFor returns the 
+// error from ctx.
+//
+// i must implement Watcher, or WaitFor returns ErrNotWatchable. Note that 
+// changes from delayed bindings are only seen when the Indicator is read.
+func WaitFor(ctx context.Context, i Initiator, pred func(interface{}) bool) (interface{}, error) {
+	w,ok := i.(Watcher)
+	if !ok {
+		return nil, ErrNotWatchable
+	}
+
+	ctx,cancel := context.WithCancel(ctx)
+	defer cancel()
+	// subscribe before checking, so no write is missed in between
+	ch := w.Watch(ctx, Buffered(waitBuffer))
+
+	if v := i.Value(); pred(v) {
+		return v, nil
+	}
+
+	for {
+		select {
+		case c,ok := <-ch:
+			if !ok {
+				return nil, ctx.Err()
+			}
+			if pred(c.Value) {
+				return c.Value, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package reactor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForCurrent(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(10)
+
+	v,err := WaitFor(context.Background(), &trigger, func(v interface{}) bool {
+		return v.(int) >= 10
+	})
+	if err != nil || v != 10 {
+		t.Fatalf("Expected (10, <nil>); got (%v, %v)", v, err)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(0)
+	ctx,cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		for i:=1; i<=10; i++ {
+			trigger.SetValue(i)
+		}
+	}()
+
+	v,err := WaitFor(ctx, &trigger, func(v interface{}) bool {
+		return v.(int) % 4 == 0 && v.(int) > 0
+	})
+	if err != nil || v != 4 {
+		t.Fatalf("Expected first satisfying value (4, <nil>); got (%v, %v)", v, err)
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	var trigger Trigger
+	trigger.SetValue(0)
+	ctx,cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_,err := WaitFor(ctx, &trigger, func(v interface{}) bool {
+		return v.(int) > 0
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v; got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWaitForIndicator(t *testing.T) {
+	var ind Indicator
+	var trigger Trigger
+	ind.AddBinding(&trigger, func(v interface{}) interface{} {
+		return v.(string) + "!"
+	})
+
+	go trigger.SetValue("ready")
+	v,err := WaitFor(context.Background(), &ind, func(v interface{}) bool {
+		return v == "ready!"
+	})
+	if err != nil || v != "ready!" {
+		t.Fatalf("Expected (ready!, <nil>); got (%v, %v)", v, err)
+	}
+}
+
+type plainInitiator struct {
+	Trigger
+}
+
+func (p *plainInitiator) Watch() {}
+
+func TestWaitForNotWatchable(t *testing.T) {
+	var p plainInitiator
+	_,err := WaitFor(context.Background(), &p, func(interface{}) bool { return true })
+	if err != ErrNotWatchable {
+		t.Fatalf("Expected %v; got %v", ErrNotWatchable, err)
+	}
+}
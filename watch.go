@@ -0,0 +1,136 @@
+package reactor
+
+import (
+	"context"
+	"sync"
+)
+
+// Change represents a single write event, as delivered by Watch. Prev and
+// Value are the same values that would be passed to a WriteCallback for the
+// same event.
+type Change struct {
+	Prev interface{}
+	Value interface{}
+}
+
+// WatchMode determines how a channel returned by Watch handles changes that
+// are made faster than they are received.
+type WatchMode int
+
+// Latest is the WatchMode in which only the most recent change is kept. If a
+// change has not been received by the time the next one is made, the older
+// change is discarded. Writers are never blocked by the channel.
+const Latest WatchMode = -1
+
+// Buffered returns the WatchMode in which up to n changes are queued in the
+// channel. No changes are discarded, so once the channel is full, writers are
+// blocked until there is room or the watch is cancelled.
+func Buffered(n int) WatchMode {
+	if n < 0 {
+		n = 0
+	}
+	return WatchMode(n)
+}
+
+// Watcher is the interface that wraps the Watch method.
+//
+// Watch returns a channel that receives a Change for every write to the
+// Watcher, until ctx is cancelled. Once ctx is cancelled, the subscription is
+// removed and the channel is closed.
+type Watcher interface {
+	Initiator
+	Watch(context.Context, WatchMode) <-chan Change
+}
+
+// subscription is a single channel returned by Watchers.Add.
+type subscription struct {
+	lock sync.Mutex
+	ch chan Change
+	latest bool
+	done <-chan struct{}
+	closed bool
+}
+
+func (s *subscription) send(c Change) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+
+	if s.latest {
+		select {
+		case <-s.ch:
+		default:
+		}
+		s.ch <- c
+		return
+	}
+
+	select {
+	case s.ch <- c:
+	case <-s.done:
+	}
+}
+
+func (s *subscription) close() {
+	s.lock.Lock()
+		s.closed = true
+		close(s.ch)
+	s.lock.Unlock()
+}
+
+// Watchers is a set of channels subscribed to the changes of an Initiator.
+// The zero value is an empty set, ready to use. It is safe to use Watchers
+// from multiple goroutines.
+//
+// Watchers is intended for use in implementing Watch for Initiators outside
+// of this package, and its use is discouraged otherwise.
+type Watchers struct {
+	lock sync.Mutex
+	subs []*subscription
+}
+
+// Add returns a new channel that will receive every Change passed to Notify
+// until ctx is cancelled, at which point it is removed from w and closed.
+func (w *Watchers) Add(ctx context.Context, mode WatchMode) <-chan Change {
+	size := int(mode)
+	if mode == Latest {
+		size = 1
+	}
+	s := &subscription{ch: make(chan Change, size), latest: mode == Latest, done: ctx.Done()}
+
+	w.lock.Lock()
+		w.subs = append(w.subs, s)
+	w.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		w.remove(s)
+		s.close()
+	}()
+	return s.ch
+}
+
+func (w *Watchers) remove(s *subscription) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for i,x := range w.subs {
+		if x == s {
+			w.subs = append(w.subs[:i:i], w.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Notify sends Change{prev, v} to every channel in w, according to the
+// WatchMode each was added with.
+func (w *Watchers) Notify(prev, v interface{}) {
+	w.lock.Lock()
+		subs := w.subs
+	w.lock.Unlock()
+
+	for _,s := range subs {
+		s.send(Change{prev, v})
+	}
+}
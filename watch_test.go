@@ -0,0 +1,96 @@
+package reactor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchBuffered(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := trigger.Watch(ctx, Buffered(10))
+	for i:=0; i<5; i++ {
+		trigger.SetValue(i)
+	}
+
+	for i:=0; i<5; i++ {
+		c := <-ch
+		if c.Value != i {
+			t.Fatalf("Expected change %d in order; got %v", i, c.Value)
+		}
+		if i > 0 && c.Prev != i-1 {
+			t.Fatalf("Expected previous value %d; got %v", i-1, c.Prev)
+		}
+	}
+}
+
+func TestWatchLatest(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := trigger.Watch(ctx, Latest)
+	for i:=0; i<100; i++ {
+		trigger.SetValue(i)
+	}
+
+	c := <-ch
+	if c.Prev != 98 || c.Value != 99 {
+		t.Fatalf("Expected only latest change (98, 99); got (%v, %v)", c.Prev, c.Value)
+	}
+	select {
+	case c = <-ch:
+		t.Fatalf("Expected no further changes; got %v", c)
+	default:
+	}
+}
+
+func TestWatchCancel(t *testing.T) {
+	var ind Indicator
+	ctx,cancel := context.WithCancel(context.Background())
+
+	ch := ind.Watch(ctx, Buffered(0))
+	cancel()
+
+	select {
+	case _,ok := <-ch:
+		if ok {
+			t.Fatal("Expected channel to be closed without changes")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Channel not closed after cancellation")
+	}
+
+	// a blocked subscription must not block writers once it is removed
+	ind.SetValue(1)
+	ind.watchers.lock.Lock()
+		n := len(ind.watchers.subs)
+	ind.watchers.lock.Unlock()
+	if n != 0 {
+		t.Fatalf("Expected subscription to be removed; %d remain", n)
+	}
+}
+
+func TestWatchBatch(t *testing.T) {
+	var trigger Trigger
+	ctx,cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := trigger.Watch(ctx, Buffered(10))
+	Batch(func() {
+		trigger.SetValue(1)
+		trigger.SetValue(2)
+	})
+
+	if c := <-ch; c.Prev != nil || c.Value != 2 {
+		t.Fatalf("Expected single change (<nil>, 2); got %v", c)
+	}
+	select {
+	case c := <-ch:
+		t.Fatalf("Expected no further changes; got %v", c)
+	default:
+	}
+}
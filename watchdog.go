@@ -0,0 +1,241 @@
+package reactor
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SlowCall describes a callback or BindingFunc that ran for longer than the
+// threshold of the Watchdog.
+//
+// Source is the name of the Initiator the callback or binding was added to,
+// and Kind is the kind of event it ran for: ReadEvent or WriteEvent for
+// callbacks, or BindingEvent or DelayedBindingEvent for bindings, for
+// example. For bindings, Target is the name of the other Initiator involved,
+// as described for Event. File, Line and Function locate the code that added
+// the callback or binding, outside of this module.
+type SlowCall struct {
+	Source string
+	Target string
+	Kind EventKind
+	Duration time.Duration
+	File string
+	Line int
+	Function string
+}
+
+func (s SlowCall) String() string {
+	what := "callback"
+	switch s.Kind {
+	case BindingEvent, DelayedBindingEvent, ConcurrentBindingEvent:
+		what = "binding"
+	}
+	name := s.Source
+	if s.Target != "" {
+		name += " -> " + s.Target
+	}
+	return fmt.Sprintf("reactor: slow %s %s on %q took %v, added at %s:%d (%s)",
+	                   s.Kind, what, name, s.Duration, s.File, s.Line, s.Function)
+}
+
+// Watchdog reports callbacks and BindingFuncs that run for longer than a
+// threshold. Only callbacks and bindings that run inline are measured, such
+// as write callbacks run by SetValue, or delayed bindings run by Value. Async
+// and concurrent callbacks are measured for the time taken to start or queue
+// them, so a full queue is reported as well.
+type Watchdog struct {
+	// Threshold is the longest a callback or BindingFunc may run without
+	// being reported, unless its Initiator has a threshold of its own, set
+	// with SetSlowThreshold. If neither is positive, nothing is reported.
+	Threshold time.Duration
+
+	// Report is called with every slow call, on the goroutine that made it,
+	// once it has returned. If Report is nil, slow calls are logged with the
+	// log package.
+	Report func(SlowCall)
+}
+
+// watchdogBox allows a nil *Watchdog to be stored in an atomic.Value.
+type watchdogBox struct {
+	w *Watchdog
+}
+
+var globalWatchdog atomic.Value
+
+// SetWatchdog sets the Watchdog used to measure every callback and binding.
+// Callbacks and bindings are measured whenever a Watchdog is set, including
+// those added before it was. A nil Watchdog disables measurement, which is
+// the default. w must not be changed after it is set.
+func SetWatchdog(w *Watchdog) {
+	globalWatchdog.Store(watchdogBox{w})
+}
+
+func currentWatchdog() *Watchdog {
+	b,_ := globalWatchdog.Load().(watchdogBox)
+	return b.w
+}
+
+// SetSlowThreshold sets the threshold used by the Watchdog for the callbacks
+// and bindings of p, in place of Watchdog.Threshold. A threshold of 0 uses
+// the Watchdog's threshold again.
+func (p *Probe) SetSlowThreshold(d time.Duration) {
+	p.lock.Lock()
+		p.slow = d
+	p.lock.Unlock()
+}
+
+// modulePath is the import path of this module. Frames in its packages are
+// skipped when finding the site that added a callback, except in tests.
+const modulePath = "github.com/KellenWatt/reactor"
+
+// site is the call stack that added a callback, resolved only when the
+// callback is reported.
+type site []uintptr
+
+// captures the call stack of the caller of the function calling captureSite.
+func captureSite() site {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	return site(pcs[:n])
+}
+
+func internalFrame(f runtime.Frame) bool {
+	if strings.HasSuffix(f.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(f.Function, modulePath + ".") ||
+	       strings.HasPrefix(f.Function, modulePath + "/") ||
+	       strings.HasPrefix(f.Function, "runtime.")
+}
+
+// returns the first frame of s outside of this module, or the first frame
+// if there is none.
+func (s site) frame() runtime.Frame {
+	frames := runtime.CallersFrames(s)
+	var first runtime.Frame
+	for {
+		f,more := frames.Next()
+		if first.PC == 0 {
+			first = f
+		}
+		if !internalFrame(f) {
+			return f
+		}
+		if !more {
+			return first
+		}
+	}
+}
+
+// watch is a callback or binding being measured.
+type watch struct {
+	probe *Probe
+	kind EventKind
+	target interface{}
+	site site
+}
+
+// starts measuring a call, returning the Watchdog measuring it, if there
+// is one.
+func (w *watch) start() (*Watchdog, time.Time) {
+	d := currentWatchdog()
+	if d == nil {
+		return nil, time.Time{}
+	}
+	return d, time.Now()
+}
+
+// reports the call started at start, if it was too slow.
+func (w *watch) end(d *Watchdog, start time.Time) {
+	took := time.Since(start)
+	w.probe.lock.Lock()
+		threshold := w.probe.slow
+	w.probe.lock.Unlock()
+	if threshold <= 0 {
+		threshold = d.Threshold
+	}
+	if threshold <= 0 || took <= threshold {
+		return
+	}
+
+	f := w.site.frame()
+	call := SlowCall{
+		Source: w.probe.Name(),
+		Target: nameOf(w.target),
+		Kind: w.kind,
+		Duration: took,
+		File: f.File,
+		Line: f.Line,
+		Function: f.Function,
+	}
+	if d.Report != nil {
+		d.Report(call)
+		return
+	}
+	log.Print(call)
+}
+
+// WatchRead returns r wrapped to be measured by the Watchdog as a callback of
+// p for events of kind, recording the site that added it. A nil r is
+// returned unchanged.
+//
+// WatchRead is intended for use in implementing Initiators outside of this
+// package, and its use is discouraged otherwise.
+func WatchRead(p *Probe, kind EventKind, r ReadCallback) ReadCallback {
+	if r == nil {
+		return nil
+	}
+	w := &watch{probe: p, kind: kind, site: captureSite()}
+	return func(v interface{}) {
+		d,start := w.start()
+		r(v)
+		if d != nil {
+			w.end(d, start)
+		}
+	}
+}
+
+// WatchWrite returns c wrapped to be measured by the Watchdog, as described
+// for WatchRead.
+//
+// WatchWrite is intended for use in implementing Initiators outside of this
+// package, and its use is discouraged otherwise.
+func WatchWrite(p *Probe, kind EventKind, c WriteCallback) WriteCallback {
+	if c == nil {
+		return nil
+	}
+	w := &watch{probe: p, kind: kind, site: captureSite()}
+	return func(prev, v interface{}) {
+		d,start := w.start()
+		c(prev, v)
+		if d != nil {
+			w.end(d, start)
+		}
+	}
+}
+
+// WatchBinding returns f wrapped to be measured by the Watchdog as a binding
+// of p for events of kind, as described for WatchRead. target is the other
+// Initiator involved, as described for Event. Only f is measured, not the
+// setting of its Binder.
+//
+// WatchBinding is intended for use in implementing Initiators outside of this
+// package, and its use is discouraged otherwise.
+func WatchBinding(p *Probe, kind EventKind, target interface{}, f BindingFunc) BindingFunc {
+	if f == nil {
+		return nil
+	}
+	w := &watch{probe: p, kind: kind, target: target, site: captureSite()}
+	return func(v interface{}) interface{} {
+		d,start := w.start()
+		val := f(v)
+		if d != nil {
+			w.end(d, start)
+		}
+		return val
+	}
+}
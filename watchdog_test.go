@@ -0,0 +1,157 @@
+package reactor
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// returns a channel receiving every slow call over threshold, until the end
+// of the test.
+func watchSlowCalls(t *testing.T, threshold time.Duration) <-chan SlowCall {
+	calls := make(chan SlowCall, 10)
+	SetWatchdog(&Watchdog{Threshold: threshold, Report: func(s SlowCall) {
+		calls <- s
+	}})
+	t.Cleanup(func() {SetWatchdog(nil)})
+	return calls
+}
+
+// returns the line after the line of its caller.
+func nextLine() int {
+	_,_,n,_ := runtime.Caller(1)
+	return n+1
+}
+
+func TestWatchdogSlowWriteCallback(t *testing.T) {
+	calls := watchSlowCalls(t, time.Millisecond)
+	var trigger Trigger
+	trigger.SetName("shared")
+
+	trigger.AddWriteCallback(func(prev, v interface{}) {})
+	slow := nextLine()
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		time.Sleep(5*time.Millisecond)
+	})
+	trigger.SetValue(1)
+
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 slow call; got %d", len(calls))
+	}
+	c := <-calls
+	if c.Source != "shared" || c.Kind != WriteEvent || c.Duration < 5*time.Millisecond {
+		t.Fatalf("Unexpected slow call %+v", c)
+	}
+	if !strings.HasSuffix(c.File, "watchdog_test.go") || c.Line != slow {
+		t.Fatalf("Expected the site at watchdog_test.go:%d; got %s:%d", slow, c.File, c.Line)
+	}
+	if !strings.HasSuffix(c.Function, "TestWatchdogSlowWriteCallback") {
+		t.Fatalf("Unexpected function %q", c.Function)
+	}
+}
+
+func TestWatchdogSlowBinding(t *testing.T) {
+	calls := watchSlowCalls(t, time.Millisecond)
+	var trigger Trigger
+	var ind Indicator
+	trigger.SetName("source")
+	ind.SetName("derived")
+
+	want := nextLine()
+	ind.AddBinding(&trigger, func(v interface{}) interface{} {
+		time.Sleep(5*time.Millisecond)
+		return v
+	})
+	trigger.SetValue(1)
+
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 slow call; got %d", len(calls))
+	}
+	c := <-calls
+	if c.Kind != BindingEvent || c.Source != "source" || c.Target != "derived" || c.Line != want {
+		t.Fatalf("Unexpected slow call %+v; want line %d", c, want)
+	}
+	if !strings.Contains(c.String(), `binding on "source -> derived"`) {
+		t.Fatalf("Unexpected description %q", c)
+	}
+}
+
+func TestWatchdogFallibleBindingExcludesBinder(t *testing.T) {
+	calls := watchSlowCalls(t, time.Millisecond)
+	var trigger Trigger
+	var ind Indicator
+	ind.AddFallibleBinding(&trigger, func(v interface{}) (interface{}, error) {
+		return v, nil
+	})
+	ind.AddWriteCallback(func(prev, v interface{}) {
+		time.Sleep(5*time.Millisecond)
+	})
+	trigger.SetValue(1)
+
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 slow call; got %d", len(calls))
+	}
+	if c := <-calls; c.Kind != WriteEvent {
+		t.Fatalf("Expected only the write callback to be slow; got %+v", c)
+	}
+}
+
+func TestWatchdogDelayedBinding(t *testing.T) {
+	calls := watchSlowCalls(t, time.Millisecond)
+	var trigger Trigger
+	var ind Indicator
+
+	ind.AddDelayedBinding(&trigger, func(v interface{}) interface{} {
+		time.Sleep(5*time.Millisecond)
+		return v
+	})
+	trigger.SetValue(1)
+	if len(calls) != 0 {
+		t.Fatal("Expected delayed binding not to run until read")
+	}
+
+	ind.Value()
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 slow call; got %d", len(calls))
+	}
+	if c := <-calls; c.Kind != DelayedBindingEvent {
+		t.Fatalf("Unexpected slow call %+v", c)
+	}
+}
+
+func TestWatchdogThresholds(t *testing.T) {
+	calls := watchSlowCalls(t, time.Hour)
+	var strict, lax Trigger
+	strict.SetSlowThreshold(time.Millisecond)
+	slow := ReadCallback(func(v interface{}) {
+		time.Sleep(5*time.Millisecond)
+	})
+	strict.AddReadCallback(slow)
+	lax.AddReadCallback(slow)
+
+	lax.Value()
+	if len(calls) != 0 {
+		t.Fatal("Expected the global threshold to apply without one of its own")
+	}
+	strict.Value()
+	if len(calls) != 1 {
+		t.Fatal("Expected the Initiator's own threshold to apply")
+	}
+	if c := <-calls; c.Kind != ReadEvent {
+		t.Fatalf("Unexpected slow call %+v", c)
+	}
+}
+
+func TestWatchdogDisabled(t *testing.T) {
+	SetWatchdog(nil)
+	var trigger Trigger
+	called := false
+	trigger.AddWriteCallback(func(prev, v interface{}) {
+		called = true
+	})
+	trigger.SetValue(1)
+	if !called {
+		t.Fatal("Expected callbacks to run without a Watchdog")
+	}
+}